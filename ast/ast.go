@@ -194,6 +194,61 @@ func (a *Array) String() string {
 	return b.String()
 }
 
+type Set struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (s *Set) e() {}
+
+func (s *Set) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *Set) String() string {
+	var b bytes.Buffer
+
+	elms := []string{}
+	for _, e := range s.Elements {
+		elms = append(elms, e.String())
+	}
+
+	b.WriteString("#{")
+	b.WriteString(strings.Join(elms, ", "))
+	b.WriteString("}")
+
+	return b.String()
+}
+
+type Tuple struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (t *Tuple) e() {}
+
+func (t *Tuple) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *Tuple) String() string {
+	var b bytes.Buffer
+
+	elms := []string{}
+	for _, e := range t.Elements {
+		elms = append(elms, e.String())
+	}
+
+	b.WriteString("(")
+	b.WriteString(strings.Join(elms, ", "))
+	if len(elms) == 1 {
+		b.WriteString(",")
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
 type PrefixExpression struct {
 	Token token.Token
 	Op    string
@@ -373,9 +428,83 @@ func (c *Index) String() string {
 	return b.String()
 }
 
+// Slice is an index expression over a range, like a[1:3]. Low and High are
+// nil when omitted.
+type Slice struct {
+	Token token.Token
+	Left  Expression
+	Low   Expression
+	High  Expression
+}
+
+func (s *Slice) e() {}
+
+func (s *Slice) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *Slice) String() string {
+	var b bytes.Buffer
+
+	b.WriteString("(")
+	b.WriteString(s.Left.String())
+	b.WriteString("[")
+	if s.Low != nil {
+		b.WriteString(s.Low.String())
+	}
+	b.WriteString(":")
+	if s.High != nil {
+		b.WriteString(s.High.String())
+	}
+	b.WriteString("])")
+
+	return b.String()
+}
+
+// Range is a range of numbers, like 1..10, 0..<n or 1..10 step 2. Step is nil
+// when omitted.
+type Range struct {
+	Token     token.Token
+	Start     Expression
+	End       Expression
+	Step      Expression
+	Inclusive bool
+}
+
+func (r *Range) e() {}
+
+func (r *Range) TokenLiteral() string {
+	return r.Token.Literal
+}
+
+func (r *Range) String() string {
+	var b bytes.Buffer
+
+	b.WriteString("(")
+	b.WriteString(r.Start.String())
+	if r.Inclusive {
+		b.WriteString("..")
+	} else {
+		b.WriteString("..<")
+	}
+	b.WriteString(r.End.String())
+	if r.Step != nil {
+		b.WriteString(" step ")
+		b.WriteString(r.Step.String())
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
 type Hash struct {
 	Token token.Token
-	Pairs map[Expression]Expression
+	Pairs []HashPair // in source order
 }
 
 func (h *Hash) e() {}
@@ -388,8 +517,8 @@ func (h *Hash) String() string {
 	var b bytes.Buffer
 
 	pairs := []string{}
-	for k, v := range h.Pairs {
-		pairs = append(pairs, k.String()+": "+v.String())
+	for _, p := range h.Pairs {
+		pairs = append(pairs, p.Key.String()+": "+p.Value.String())
 	}
 
 	b.WriteString("{")
@@ -10,13 +10,13 @@ func TestString(t *testing.T) {
 	m := &Module{
 		Statements: []Statement{
 			&LetStatement{
-				Token: token.Token{token.Let, "let", 0, 0},
+				Token: token.Token{Type: token.Let, Literal: "let"},
 				Name: &Id{
-					Token: token.Token{token.Id, "myVar", 0, 0},
+					Token: token.Token{Type: token.Id, Literal: "myVar"},
 					Value: "myVar",
 				},
 				Value: &Id{
-					Token: token.Token{token.Id, "anotherVar", 0, 0},
+					Token: token.Token{Type: token.Id, Literal: "anotherVar"},
 					Value: "anotherVar",
 				},
 			},
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/geovanisouza92/geo/ast"
+	"github.com/geovanisouza92/geo/lexer"
+	"github.com/geovanisouza92/geo/token"
+)
+
+// dumpTokens writes the tokens of the source as the lexer reads them, one per
+// line, up to and including EOF.
+func dumpTokens(w io.Writer, src string) {
+	l := lexer.New(strings.NewReader(src))
+	for {
+		t := l.NextToken()
+		fmt.Fprintf(w, "%d:%d\t%s\t%q\n", t.Line, t.StartCol(), t.Type, t.Literal)
+		if t.Type == token.EOF {
+			return
+		}
+	}
+}
+
+// node is an AST node as dumped: its type, where it starts and its fields,
+// in declaration order.
+type node struct {
+	typ    string
+	line   int
+	col    int
+	fields []field
+}
+
+type field struct {
+	name  string
+	value interface{} // *node, []interface{} or a plain value
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// toNode walks the AST through reflection, so new node types and fields are
+// dumped without changes here.
+func toNode(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return toNode(v.Elem())
+
+	case reflect.Slice:
+		elms := make([]interface{}, v.Len())
+		for i := range elms {
+			elms[i] = toNode(v.Index(i))
+		}
+		return elms
+
+	case reflect.Struct:
+		n := &node{typ: v.Type().Name()}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Type == tokenType {
+				t := v.Field(i).Interface().(token.Token)
+				n.line, n.col = t.Line, t.StartCol()
+				continue
+			}
+			n.fields = append(n.fields, field{f.Name, toNode(v.Field(i))})
+		}
+		return n
+
+	default:
+		return v.Interface()
+	}
+}
+
+// dumpAST writes m as an indented tree, one field per line.
+func dumpAST(w io.Writer, m *ast.Module) {
+	fmt.Fprint(w, "Module")
+	writeText(w, toNode(reflect.ValueOf(m)), 0)
+}
+
+// writeText writes value after the label already on the line, as in
+// "Left: Id 1:5".
+func writeText(w io.Writer, value interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch value := value.(type) {
+	case *node:
+		if value.line > 0 {
+			fmt.Fprintf(w, " %s %d:%d\n", value.typ, value.line, value.col)
+		} else if depth > 0 {
+			fmt.Fprintf(w, " %s\n", value.typ)
+		} else {
+			fmt.Fprintln(w)
+		}
+		for _, f := range value.fields {
+			fmt.Fprintf(w, "%s  %s:", indent, f.name)
+			writeText(w, f.value, depth+1)
+		}
+
+	case []interface{}:
+		if len(value) == 0 {
+			fmt.Fprintln(w, " []")
+			return
+		}
+		fmt.Fprintln(w)
+		for _, elm := range value {
+			fmt.Fprintf(w, "%s  -", indent)
+			writeText(w, elm, depth+1)
+		}
+
+	case nil:
+		fmt.Fprintln(w, " nil")
+
+	case string:
+		fmt.Fprintf(w, " %q\n", value)
+
+	default:
+		fmt.Fprintf(w, " %v\n", value)
+	}
+}
+
+// dumpASTJSON writes m as JSON. Nodes are objects with their type in "type",
+// their position in "line" and "col", and then their fields.
+func dumpASTJSON(w io.Writer, m *ast.Module) error {
+	var b bytes.Buffer
+	if err := writeJSON(&b, toNode(reflect.ValueOf(m))); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// writeJSON writes nodes by hand, as maps would lose the order of the fields.
+func writeJSON(b *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case *node:
+		fmt.Fprintf(b, `{"type":%q`, value.typ)
+		if value.line > 0 {
+			fmt.Fprintf(b, `,"line":%d,"col":%d`, value.line, value.col)
+		}
+		for _, f := range value.fields {
+			fmt.Fprintf(b, ",%q:", f.name)
+			if err := writeJSON(b, f.value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+
+	case []interface{}:
+		b.WriteByte('[')
+		for i, elm := range value {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, elm); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+
+	default:
+		enc, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		b.Write(enc)
+	}
+
+	return nil
+}
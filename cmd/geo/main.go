@@ -3,44 +3,352 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/geovanisouza92/geo/ast"
 	"github.com/geovanisouza92/geo/eval"
 	"github.com/geovanisouza92/geo/object"
+	"github.com/geovanisouza92/geo/parser"
 	"github.com/geovanisouza92/geo/repl"
 )
 
+// Exit statuses, following sysexits.h. Scripts calling exit choose their own.
+const (
+	exitUsage   = 64 // bad command line
+	exitCompile = 65 // the script does not parse
+	exitNoInput = 66 // the script can't be read
+	exitRuntime = 70 // the script raised an error
+)
+
+const usage = `usage:
+  geo [flags] [file.geo [-- args...]]   run a script, or start the REPL
+  geo run [flags] file.geo [-- args...]  run a script
+  geo eval [flags] -e 'expr' [args...]   run the given expression
+  geo check file.geo...                  report parse errors and unknown names
+  geo tokens file.geo                    print the tokens of a script
+  geo ast [-json] file.geo               print the syntax tree of a script
+
+Scripts are read from the standard input when given as -.
+Run geo <command> -h for the flags of a command.
+`
+
+var commands = map[string]func(c *cli, args []string) int{
+	"run":    (*cli).runCommand,
+	"eval":   (*cli).evalCommand,
+	"check":  (*cli).checkCommand,
+	"tokens": (*cli).tokensCommand,
+	"ast":    (*cli).astCommand,
+}
+
+// cli is where the commands read and write, so they can be run on buffers.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
 func main() {
-	flag.Parse()
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(os.Args[1:]))
+}
 
-	if flag.NArg() == 0 {
-		repl.Start(os.Stdin, os.Stdout)
-		return
+// main runs the command in args and returns the status to exit with.
+func (c *cli) main(args []string) int {
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd(c, args[1:])
+		}
+		if args[0] == "help" {
+			fmt.Fprint(c.stdout, usage)
+			return 0
+		}
+	}
+
+	// Without a command, geo runs the script given, if any
+	fs, rt := c.runtimeFlags("geo")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage, "\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		return repl.StartWith(c.stdin, c.stdout, repl.Options{Setup: rt.setup})
+	}
+	return c.runFile(rt, fs.Args())
+}
+
+func (c *cli) runCommand(args []string) int {
+	fs, rt := c.runtimeFlags("run")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(c.stderr, "geo run: missing script to run")
+		return exitUsage
+	}
+	return c.runFile(rt, fs.Args())
+}
+
+func (c *cli) evalCommand(args []string) int {
+	fs, rt := c.runtimeFlags("eval")
+	expr := fs.String("e", "", "expression to run")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *expr == "" {
+		fmt.Fprintln(c.stderr, "geo eval: missing expression; give it with -e")
+		return exitUsage
 	}
 
-	c := eval.NewContext(object.NewRootScope())
-	m := compileFile(flag.Arg(0))
-	if m != nil {
-		ev := c.Eval(m)
-		if ev != eval.Null {
-			fmt.Print(ev.String())
+	m, code := c.compile("-e", *expr)
+	if m == nil {
+		return code
+	}
+	return c.run(rt, "-e", m, fs.Args())
+}
+
+func (c *cli) checkCommand(args []string) int {
+	fs := c.flagSet("check")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(c.stderr, "geo check: missing scripts to check")
+		return exitUsage
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		m, code := c.compileFile(path)
+		if m != nil {
+			if errs := eval.NewContext(object.NewRootScope()).Check(m); len(errs) > 0 {
+				c.reportErrors(sourceName(path), errs)
+				code = exitCompile
+			}
+		}
+		if code > status {
+			status = code
 		}
 	}
+	return status
 }
 
-func compileFile(path string) *ast.Module {
-	b, err := ioutil.ReadFile(path)
+func (c *cli) tokensCommand(args []string) int {
+	fs := c.flagSet("tokens")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "geo tokens: want one script")
+		return exitUsage
+	}
+
+	src, err := c.readSource(fs.Arg(0))
 	if err != nil {
-		fmt.Print(err.Error())
-		return nil
+		fmt.Fprintln(c.stderr, err)
+		return exitNoInput
+	}
+	dumpTokens(c.stdout, src)
+	return 0
+}
+
+func (c *cli) astCommand(args []string) int {
+	fs := c.flagSet("ast")
+	asJSON := fs.Bool("json", false, "print the tree as JSON")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "geo ast: want one script")
+		return exitUsage
 	}
-	input := string(b)
-	s, err := eval.Compile(input)
+
+	m, code := c.compileFile(fs.Arg(0))
+	if m == nil {
+		return code
+	}
+	if *asJSON {
+		if err := dumpASTJSON(c.stdout, m); err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return exitRuntime
+		}
+		return 0
+	}
+	dumpAST(c.stdout, m)
+	return 0
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parseFlags parses args into fs. When it fails, or only help was asked for,
+// it tells the status to exit with.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0, false
+		}
+		return exitUsage, false
+	}
+	return 0, true
+}
+
+// runtime holds the flags that set up the context scripts run in.
+type runtime struct {
+	seed      int64
+	seeded    bool
+	allowFS   string
+	readOnly  bool
+	allowEnv  bool
+	quiet     bool
+	precision int
+}
+
+func (c *cli) runtimeFlags(name string) (*flag.FlagSet, *runtime) {
+	rt := &runtime{}
+	fs := c.flagSet(name)
+	fs.IntVar(&rt.precision, "precision", -1, "decimals to print numbers with, or -1 for as many as needed")
+	fs.Func("seed", "seed for random numbers, to repeat runs", func(s string) error {
+		_, err := fmt.Sscan(s, &rt.seed)
+		rt.seeded = err == nil
+		return err
+	})
+	fs.StringVar(&rt.allowFS, "allow-fs", "", "directories scripts may access files in, separated by "+string(os.PathListSeparator))
+	fs.BoolVar(&rt.readOnly, "read-only", false, "forbid scripts to write files")
+	fs.BoolVar(&rt.allowEnv, "allow-env", false, "allow scripts to read environment variables")
+	fs.BoolVar(&rt.quiet, "quiet", false, "do not print the value of the script")
+	return fs, rt
+}
+
+// runFile runs the script at args[0] with the arguments meant for it.
+func (c *cli) runFile(rt *runtime, args []string) int {
+	path, scriptArgs, err := splitArgs(args)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+		return exitUsage
+	}
+
+	m, code := c.compileFile(path)
+	if m == nil {
+		return code
+	}
+	return c.run(rt, sourceName(path), m, scriptArgs)
+}
+
+// run evaluates m and prints its value. name tells where m came from in error
+// messages.
+func (c *cli) run(rt *runtime, name string, m *ast.Module, args []string) int {
+	ctx := eval.NewContext(object.NewRootScope())
+	ctx.SetArgs(args)
+	ctx.SetStdin(c.stdin)
+	ctx.SetStdout(c.stdout)
+	ctx.SetStderr(c.stderr)
+	rt.setup(ctx)
+
+	ev := ctx.Eval(m)
+	if code, ok := eval.ExitCode(ev); ok {
+		return code
+	}
+	if err, ok := ev.(*object.Error); ok {
+		if err.Line > 0 {
+			fmt.Fprintf(c.stderr, "%s:%d:%d: %s\n", name, err.Line, err.Col, err.Message)
+		} else {
+			fmt.Fprintf(c.stderr, "%s: %s\n", name, err.Message)
+		}
+		return exitRuntime
+	}
+	if !rt.quiet && ev != nil && ev != eval.Null {
+		fmt.Fprintln(c.stdout, ctx.Format(ev))
+	}
+	return 0
+}
+
+// setup applies the flags to c.
+func (rt *runtime) setup(c *eval.Context) {
+	caps := eval.Capabilities{ReadOnly: rt.readOnly, Env: rt.allowEnv}
+	if rt.allowFS != "" {
+		caps.Roots = filepath.SplitList(rt.allowFS)
+	}
+	c.Grant(caps)
+	if rt.seeded {
+		c.Seed(rt.seed)
+	}
+	c.SetPrecision(rt.precision)
+}
+
+// compileFile reads and parses the script at path. When it can't, it reports
+// why and returns the status to exit with.
+func (c *cli) compileFile(path string) (*ast.Module, int) {
+	src, err := c.readSource(path)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+		return nil, exitNoInput
+	}
+	return c.compile(sourceName(path), src)
+}
+
+// readSource reads the script at path, or from the standard input when path
+// is -, as in `generate | geo -`.
+func (c *cli) readSource(path string) (string, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(c.stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	return string(b), err
+}
+
+// sourceName names the script at path in messages.
+func sourceName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
+
+func (c *cli) compile(name, src string) (*ast.Module, int) {
+	m, err := eval.Compile(src)
 	if err != nil {
-		fmt.Print(err.Error())
-		return nil
+		if errs, ok := err.(parser.Errors); ok {
+			c.reportErrors(name, errs)
+		} else {
+			fmt.Fprintf(c.stderr, "%s: %s\n", name, err)
+		}
+		return nil, exitCompile
+	}
+	return m, 0
+}
+
+func (c *cli) reportErrors(name string, errs []error) {
+	for _, e := range errs {
+		if pe, ok := e.(*parser.Error); ok {
+			fmt.Fprintf(c.stderr, "%s:%d:%d: %s\n", name, pe.Line, pe.Col, pe.Msg)
+		} else {
+			fmt.Fprintf(c.stderr, "%s: %s\n", name, e)
+		}
+	}
+}
+
+// splitArgs tells the script apart from the arguments meant for it, which go
+// after --, as in `geo transform.geo -- -n 10`. That keeps arguments that look
+// like flags from being taken by geo.
+func splitArgs(args []string) (string, []string, error) {
+	path, rest := args[0], args[1:]
+	if len(rest) == 0 {
+		return path, nil, nil
+	}
+	if rest[0] != "--" {
+		return "", nil, fmt.Errorf("unexpected argument %q; arguments for the script go after --", rest[0])
 	}
-	return s
+	return path, rest[1:], nil
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI(t *testing.T) {
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"args.geo": "args",
+		"bad.geo":  "x + 1",
+		"good.geo": "let x = 1; x + 1",
+		"puts.geo": `puts!(read_line()); eputs!("done")`,
+	}
+	for name, src := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := func(name string) string { return filepath.Join(dir, name) }
+
+	tt := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string // only has to be in stderr, which must be empty when ""
+	}{
+		{[]string{"help"}, "", 0, usage, ""},
+		{[]string{}, "exit(3)\n", 3, ">> ", ""},
+		{[]string{}, "1\n", 0, ">> 1 : TypeNumber\n>> ", ""},
+		{[]string{"eval", "-e", "1 + 2"}, "", 0, "3\n", ""},
+		{[]string{"eval", "-e", "args", "a", "-n"}, "", 0, "[a, -n]\n", ""},
+		{[]string{"eval", "-precision", "2", "-e", "[1 / 3]"}, "", 0, "[0.33]\n", ""},
+		{[]string{"eval", "-quiet", "-e", "1"}, "", 0, "", ""},
+		{[]string{"eval", "-e", `puts!("hi"); eputs!("oops")`}, "", 0, "hi\n", "oops\n"},
+		{[]string{"eval", "-e", "exit(3)"}, "", 3, "", ""},
+		{[]string{"eval"}, "", exitUsage, "", "geo eval: missing expression; give it with -e\n"},
+		{[]string{"eval", "-bogus"}, "", exitUsage, "", "flag provided but not defined: -bogus"},
+		{[]string{"eval", "-e", "let"}, "", exitCompile, "", "-e:1:4: expected next token to be Id, got EOF instead\n"},
+		{[]string{"eval", "-e", "len(1)"}, "", exitRuntime, "", "-e:1:4: argument to `len` must be"},
+		{[]string{"run", script("args.geo"), "--", "-n", "1"}, "", 0, "[-n, 1]\n", ""},
+		{[]string{"run", script("args.geo"), "--"}, "", 0, "[]\n", ""},
+		{[]string{"run", script("args.geo"), "-n"}, "", exitUsage, "", `unexpected argument "-n"; arguments for the script go after --`},
+		{[]string{"run", script("puts.geo")}, "line\n", 0, "line\n", "done\n"},
+		{[]string{"run"}, "", exitUsage, "", "geo run: missing script to run\n"},
+		{[]string{"run", script("nope.geo")}, "", exitNoInput, "", "nope.geo: no such file or directory"},
+		{[]string{"run", "-"}, "1 + 1", 0, "2\n", ""},
+		{[]string{"run", "-"}, "len(1)", exitRuntime, "", "<stdin>:1:4: "},
+		{[]string{script("args.geo"), "--", "x"}, "", 0, "[x]\n", ""},
+		{[]string{"-quiet", script("good.geo")}, "", 0, "", ""},
+		{[]string{"-"}, "#!/usr/bin/env geo\n2 * 3", 0, "6\n", ""},
+		{[]string{"check", script("good.geo")}, "", 0, "", ""},
+		{[]string{"check", script("good.geo"), script("bad.geo")}, "", exitCompile, "", "bad.geo:1:1: identifier not found: x\n"},
+		{[]string{"check", script("bad.geo"), script("nope.geo")}, "", exitNoInput, "", "nope.geo"},
+		{[]string{"check", "-"}, "y", exitCompile, "", "<stdin>:1:1: identifier not found: y\n"},
+		{[]string{"check"}, "", exitUsage, "", "geo check: missing scripts to check\n"},
+		{[]string{"tokens", "-"}, "let x = \"é\"\nx", 0, "1:1\tLet\t\"let\"\n1:5\tId\t\"x\"\n1:7\tAssign\t\"=\"\n1:9\tString\t\"é\"\n2:1\tId\t\"x\"\n2:2\tEOF\t\"\"\n", ""},
+		{[]string{"tokens"}, "", exitUsage, "", "geo tokens: want one script\n"},
+		{[]string{"tokens", script("nope.geo")}, "", exitNoInput, "", "nope.geo"},
+		{[]string{"ast", "-"}, "1", 0, "Module\n  Statements:\n    - ExpressionStatement 1:1\n      Expression: Number 1:1\n        Value: 1\n", ""},
+		{[]string{"ast", "-json", "-"}, "1", 0, `{
+  "type": "Module",
+  "Statements": [
+    {
+      "type": "ExpressionStatement",
+      "line": 1,
+      "col": 1,
+      "Expression": {
+        "type": "Number",
+        "line": 1,
+        "col": 1,
+        "Value": 1
+      }
+    }
+  ]
+}
+`, ""},
+		{[]string{"ast", "-"}, "1 +", exitCompile, "", "<stdin>:1:"},
+		{[]string{"ast", "a.geo", "b.geo"}, "", exitUsage, "", "geo ast: want one script\n"},
+	}
+
+	for _, tc := range tt {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			c := &cli{stdin: strings.NewReader(tc.stdin), stdout: &stdout, stderr: &stderr}
+
+			if code := c.main(tc.args); code != tc.code {
+				t.Errorf("exit status should be %d; got %d (stderr %q)", tc.code, code, stderr.String())
+			}
+			if stdout.String() != tc.stdout {
+				t.Errorf("stdout should be %q; got %q", tc.stdout, stdout.String())
+			}
+			if (tc.stderr == "" && stderr.Len() > 0) || !strings.Contains(stderr.String(), tc.stderr) {
+				t.Errorf("stderr should have %q; got %q", tc.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tt := []struct {
+		args []string
+		path string
+		rest []string
+		err  string
+	}{
+		{[]string{"a.geo"}, "a.geo", nil, ""},
+		{[]string{"a.geo", "--"}, "a.geo", []string{}, ""},
+		{[]string{"a.geo", "--", "--", "-x"}, "a.geo", []string{"--", "-x"}, ""},
+		{[]string{"a.geo", "b"}, "", nil, `unexpected argument "b"; arguments for the script go after --`},
+	}
+
+	for _, tc := range tt {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			path, rest, err := splitArgs(tc.args)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("error should be %q; got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not fail; got %v", err)
+			}
+			if path != tc.path || strings.Join(rest, " ") != strings.Join(tc.rest, " ") || len(rest) != len(tc.rest) {
+				t.Errorf("should split into %q %q; got %q %q", tc.path, tc.rest, path, rest)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package eval
 
 import (
-	"fmt"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 
 	"github.com/geovanisouza92/geo/object"
 )
@@ -9,13 +10,18 @@ import (
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
 		Name:   "len",
-		Params: []object.ObjectType{object.TypeArray | object.TypeString},
+		Doc:    "len(x) is the number of elements of x, or of characters of a string.",
+		Params: []object.ObjectType{object.TypeArray | object.TypeString | object.TypeSet | object.TypeTuple},
 		Impl: func(args ...object.Object) object.Object {
 			switch arg := args[0].(type) {
 			case *object.Array:
-				return object.NewNumber(float64(len(arg.Elements)))
+				return object.NewNumber(float64(arg.Len()))
+			case *object.Set:
+				return object.NewNumber(float64(arg.Len()))
+			case *object.Tuple:
+				return object.NewNumber(float64(arg.Len()))
 			case *object.String:
-				return object.NewNumber(float64(len(arg.Value)))
+				return object.NewNumber(float64(runeCount(arg.Value)))
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
@@ -23,11 +29,12 @@ var builtins = map[string]*object.Builtin{
 	},
 	"head": &object.Builtin{
 		Name:   "head",
+		Doc:    "head(xs) is the first element of xs, or null when it is empty.",
 		Params: []object.ObjectType{object.TypeArray},
 		Impl: func(args ...object.Object) object.Object {
 			ary := args[0].(*object.Array)
-			if len(ary.Elements) > 0 {
-				return ary.Elements[0]
+			if ary.Len() > 0 {
+				return ary.At(0)
 			}
 
 			return Null
@@ -35,12 +42,13 @@ var builtins = map[string]*object.Builtin{
 	},
 	"last": &object.Builtin{
 		Name:   "last",
+		Doc:    "last(xs) is the last element of xs, or null when it is empty.",
 		Params: []object.ObjectType{object.TypeArray},
 		Impl: func(args ...object.Object) object.Object {
 			ary := args[0].(*object.Array)
-			length := len(ary.Elements)
+			length := ary.Len()
 			if length > 0 {
-				return ary.Elements[length-1]
+				return ary.At(length - 1)
 			}
 
 			return Null
@@ -48,41 +56,398 @@ var builtins = map[string]*object.Builtin{
 	},
 	"tail": &object.Builtin{
 		Name:   "tail",
+		Doc:    "tail(xs) is xs without its first element, or null when it is empty.",
 		Params: []object.ObjectType{object.TypeArray},
 		Impl: func(args ...object.Object) object.Object {
 			ary := args[0].(*object.Array)
-			length := len(ary.Elements)
+			length := ary.Len()
 			if length > 0 {
-				elms := make([]object.Object, length-1, length-1)
-				copy(elms, ary.Elements[1:length])
-				return &object.Array{elms}
+				return ary.Slice(1, length)
 			}
 
 			return Null
 		},
 	},
+	// push takes the array last, like the other collection builtins, so it
+	// can be piped: `xs | push(1)`.
 	"push": &object.Builtin{
 		Name:   "push",
-		Params: []object.ObjectType{object.TypeArray, object.TypeAny},
+		Doc:    "push(x, xs) is xs with x added at the end.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeArray},
 		Impl: func(args ...object.Object) object.Object {
-			ary := args[0].(*object.Array)
-			length := len(ary.Elements)
+			ary := args[1].(*object.Array)
+			return ary.Push(args[0])
+		},
+	},
+	// assoc and dissoc take the collection last, like the other collection
+	// builtins, so they can be piped: `xs | assoc(0, "first")`.
+	"assoc": &object.Builtin{
+		Name:   "assoc",
+		Doc:    "assoc(key, value, coll) is coll with key set to value; for arrays, key is an index up to the length.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeAny, object.TypeArray | object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			switch coll := args[2].(type) {
+			case *object.Array:
+				num, ok := args[0].(*object.Number)
+				if !ok {
+					return newError("array index must be TypeNumber, got %s", args[0].Type())
+				}
+				idx := int(num.Value)
+				switch {
+				case idx == coll.Len():
+					return coll.Push(args[1])
+				case idx < 0 || idx > coll.Len():
+					return newError("array index out of range: %d", idx)
+				default:
+					return coll.Assoc(idx, args[1])
+				}
 
-			elms := make([]object.Object, length+1, length+1)
-			copy(elms, ary.Elements)
-			elms[length] = args[1]
+			default:
+				if _, ok := args[0].(object.Hashable); !ok {
+					return newError("unusable as hash key: %s", args[0].Type())
+				}
+				return coll.(*object.Hash).Assoc(args[0], args[1])
+			}
+		},
+	},
+	"dissoc": &object.Builtin{
+		Name:   "dissoc",
+		Doc:    "dissoc(key, h) is h without key.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			return args[1].(*object.Hash).Dissoc(args[0])
+		},
+	},
+	// Hash builtins take the hash last, so they can be piped:
+	// `h | put("a", 1) | delete("b")`.
+	"keys": &object.Builtin{
+		Name:   "keys",
+		Doc:    "keys(h) are the keys of h, in insertion order.",
+		Params: []object.ObjectType{object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			pairs := args[0].(*object.Hash).Pairs()
+			elms := make([]object.Object, len(pairs))
+			for i, p := range pairs {
+				elms[i] = p.Key
+			}
+			return object.NewArray(elms)
+		},
+	},
+	"values": &object.Builtin{
+		Name:   "values",
+		Doc:    "values(h) are the values of h, in insertion order.",
+		Params: []object.ObjectType{object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			pairs := args[0].(*object.Hash).Pairs()
+			elms := make([]object.Object, len(pairs))
+			for i, p := range pairs {
+				elms[i] = p.Value
+			}
+			return object.NewArray(elms)
+		},
+	},
+	"entries": &object.Builtin{
+		Name:   "entries",
+		Doc:    "entries(h) are the (key, value) tuples of h, in insertion order.",
+		Params: []object.ObjectType{object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			pairs := args[0].(*object.Hash).Pairs()
+			elms := make([]object.Object, len(pairs))
+			for i, p := range pairs {
+				elms[i] = object.NewTuple([]object.Object{p.Key, p.Value})
+			}
+			return object.NewArray(elms)
+		},
+	},
+	"has?": &object.Builtin{
+		Name:   "has?",
+		Doc:    "has?(key, h) tells whether h has key.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			if _, ok := args[1].(*object.Hash).Get(args[0]); ok {
+				return True
+			}
 
-			return &object.Array{elms}
+			return False
 		},
 	},
-	"puts!": &object.Builtin{
-		Name: "puts!",
+	// get gives null for missing keys. It cannot take an optional default:
+	// builtins are partially applied when given fewer arguments than they
+	// have parameters, so a three-parameter get would wait for a third
+	// argument on `get("a", h)`, and since a default may itself be a hash,
+	// the argument types cannot tell the two calls apart. get_or is get with
+	// a default: `h | get_or("a", 0)`.
+	"get": &object.Builtin{
+		Name:   "get",
+		Doc:    "get(key, h) is the value of key in h, or null when it is missing.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeHash},
 		Impl: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.String())
+			if value, ok := args[1].(*object.Hash).Get(args[0]); ok {
+				return value
 			}
 
 			return Null
 		},
 	},
+	"get_or": &object.Builtin{
+		Name:   "get_or",
+		Doc:    "get_or(key, default, h) is the value of key in h, or default when it is missing.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeAny, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			if value, ok := args[2].(*object.Hash).Get(args[0]); ok {
+				return value
+			}
+
+			return args[1]
+		},
+	},
+	// merge and deep_merge give precedence to the first hash:
+	// `defaults | merge(options)` keeps the options over the defaults.
+	"merge": &object.Builtin{
+		Name:   "merge",
+		Doc:    "merge(over, h) is h with the pairs of over, which win on conflicts.",
+		Params: []object.ObjectType{object.TypeHash, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			return mergeHashes(args[1].(*object.Hash), args[0].(*object.Hash), false)
+		},
+	},
+	"deep_merge": &object.Builtin{
+		Name:   "deep_merge",
+		Doc:    "deep_merge(over, h) is merge(over, h), also merging the values that are hashes in both.",
+		Params: []object.ObjectType{object.TypeHash, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			return mergeHashes(args[1].(*object.Hash), args[0].(*object.Hash), true)
+		},
+	},
+	"select_keys": &object.Builtin{
+		Name:   "select_keys",
+		Doc:    "select_keys(keys, h) is h with only the keys given.",
+		Params: []object.ObjectType{iterableTypes, object.TypeHash},
+		Impl: func(args ...object.Object) object.Object {
+			hash := args[1].(*object.Hash)
+			result := object.NewHash()
+
+			it := iterOf(args[0])
+			for {
+				key, ok := it.Next()
+				if !ok {
+					return result
+				}
+				if isError(key) {
+					return key
+				}
+				if value, ok := hash.Get(key); ok {
+					result = result.Assoc(key, value)
+				}
+			}
+		},
+	},
+	"from_entries": &object.Builtin{
+		Name:   "from_entries",
+		Doc:    "from_entries(entries) is a hash of the (key, value) pairs in entries.",
+		Params: []object.ObjectType{iterableTypes},
+		Impl: func(args ...object.Object) object.Object {
+			hash := object.NewHash()
+
+			it := iterOf(args[0])
+			for {
+				entry, ok := it.Next()
+				if !ok {
+					return hash
+				}
+				if isError(entry) {
+					return entry
+				}
+
+				var pair []object.Object
+				switch entry := entry.(type) {
+				case *object.Tuple:
+					pair = entry.Elements()
+				case *object.Array:
+					pair = entry.Elements()
+				}
+				if len(pair) != 2 {
+					return newError("hash entry must be a pair, got %s", entry.String())
+				}
+				if _, ok := pair[0].(object.Hashable); !ok {
+					return newError("unusable as hash key: %s", pair[0].Type())
+				}
+				hash = hash.Assoc(pair[0], pair[1])
+			}
+		},
+	},
+	"slice": &object.Builtin{
+		Name:   "slice",
+		Doc:    "slice(lo, hi, x) is x[lo:hi].",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeNumber, object.TypeArray | object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			lo, okLo := toInt(args[0].(*object.Number).Value)
+			hi, okHi := toInt(args[1].(*object.Number).Value)
+			if !okLo || !okHi {
+				return newError("slice bound must not be nan")
+			}
+			return sliceOf(args[2], lo, hi)
+		},
+	},
+	"bytes": &object.Builtin{
+		Name:   "bytes",
+		Doc:    "bytes(s) are the bytes of s in UTF-8, as numbers.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			str := args[0].(*object.String).Value
+			elms := make([]object.Object, len(str))
+			for i := 0; i < len(str); i++ {
+				elms[i] = object.NewNumber(float64(str[i]))
+			}
+			return object.NewArray(elms)
+		},
+	},
+	"chars": &object.Builtin{
+		Name:   "chars",
+		Doc:    "chars(s) are the characters of s, as strings.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			clusters := graphemes(args[0].(*object.String).Value)
+			elms := make([]object.Object, len(clusters))
+			for i, c := range clusters {
+				elms[i] = object.NewString(c)
+			}
+			return object.NewArray(elms)
+		},
+	},
+	"nfc": &object.Builtin{
+		Name:   "nfc",
+		Doc:    "nfc(s) is s in Unicode normal form C, with characters composed.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(norm.NFC.String(args[0].(*object.String).Value))
+		},
+	},
+	"nfd": &object.Builtin{
+		Name:   "nfd",
+		Doc:    "nfd(s) is s in Unicode normal form D, with characters decomposed.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(norm.NFD.String(args[0].(*object.String).Value))
+		},
+	},
+	"fold": &object.Builtin{
+		Name:   "fold",
+		Doc:    "fold(s) is s case folded, for comparing strings regardless of case.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(cases.Fold().String(args[0].(*object.String).Value))
+		},
+	},
+	"set": &object.Builtin{
+		Name:   "set",
+		Doc:    "set(xs) is a set of the elements of xs.",
+		Params: []object.ObjectType{object.TypeArray | object.TypeTuple | object.TypeSet},
+		Impl: func(args ...object.Object) object.Object {
+			var elms []object.Object
+			switch arg := args[0].(type) {
+			case *object.Array:
+				elms = arg.Elements()
+			case *object.Tuple:
+				elms = arg.Elements()
+			case *object.Set:
+				return arg
+			}
+
+			for _, elm := range elms {
+				if _, ok := elm.(object.Hashable); !ok {
+					return newError("unusable as set element: %s", elm.Type())
+				}
+			}
+			return object.NewSet(elms)
+		},
+	},
+	// Set operations take the set being operated on last, so they can be
+	// piped: `a | difference(b)` are the elements of a not in b.
+	"union": &object.Builtin{
+		Name:   "union",
+		Doc:    "union(b, a) is the set of the elements in either a or b.",
+		Params: []object.ObjectType{object.TypeSet, object.TypeSet},
+		Impl: func(args ...object.Object) object.Object {
+			other, set := args[0].(*object.Set), args[1].(*object.Set)
+			for _, elm := range other.Elements() {
+				set = set.Add(elm)
+			}
+			return set
+		},
+	},
+	"intersection": &object.Builtin{
+		Name:   "intersection",
+		Doc:    "intersection(b, a) is the set of the elements in both a and b.",
+		Params: []object.ObjectType{object.TypeSet, object.TypeSet},
+		Impl: func(args ...object.Object) object.Object {
+			other, set := args[0].(*object.Set), args[1].(*object.Set)
+			for _, elm := range set.Elements() {
+				if !other.Has(elm) {
+					set = set.Remove(elm)
+				}
+			}
+			return set
+		},
+	},
+	"difference": &object.Builtin{
+		Name:   "difference",
+		Doc:    "difference(b, a) is the set of the elements of a not in b.",
+		Params: []object.ObjectType{object.TypeSet, object.TypeSet},
+		Impl: func(args ...object.Object) object.Object {
+			other, set := args[0].(*object.Set), args[1].(*object.Set)
+			for _, elm := range other.Elements() {
+				set = set.Remove(elm)
+			}
+			return set
+		},
+	},
+	"member?": &object.Builtin{
+		Name:   "member?",
+		Doc:    "member?(x, s) tells whether x is in the set s.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeSet},
+		Impl: func(args ...object.Object) object.Object {
+			if args[1].(*object.Set).Has(args[0]) {
+				return True
+			}
+
+			return False
+		},
+	},
+	"identical?": &object.Builtin{
+		Name:   "identical?",
+		Doc:    "identical?(a, b) tells whether a and b are the same value, not only equal ones.",
+		Params: []object.ObjectType{object.TypeAny, object.TypeAny},
+		Impl: func(args ...object.Object) object.Object {
+			if args[0] == args[1] {
+				return True
+			}
+
+			return False
+		},
+	},
+}
+
+func init() {
+	// put and delete are the hash names of assoc and dissoc.
+	builtins["put"] = builtins["assoc"]
+	builtins["delete"] = builtins["dissoc"]
+}
+
+// mergeHashes returns base with the pairs of over, which win on conflicts.
+// When deep, conflicting values that are both hashes are merged as well.
+func mergeHashes(base, over *object.Hash, deep bool) *object.Hash {
+	for _, p := range over.Pairs() {
+		value := p.Value
+		if deep {
+			old, ok := base.Get(p.Key)
+			oldHash, oldIsHash := old.(*object.Hash)
+			newHash, newIsHash := value.(*object.Hash)
+			if ok && oldIsHash && newIsHash {
+				value = mergeHashes(oldHash, newHash, true)
+			}
+		}
+		base = base.Assoc(p.Key, value)
+	}
+	return base
 }
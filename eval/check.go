@@ -0,0 +1,152 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/geovanisouza92/geo/ast"
+	"github.com/geovanisouza92/geo/parser"
+)
+
+// Check finds the mistakes that can be told without running m: for now, the
+// identifiers that are not defined anywhere they could be seen from. The names
+// in the context scope, builtins and constants count as defined.
+//
+// Names are visible in their whole block, before the let too, since functions
+// may refer to names defined after them, as in mutual recursion.
+func (c *Context) Check(m *ast.Module) []error {
+	ch := &checker{c: c}
+	ch.block(m.Statements, &checkScope{})
+	return ch.errs
+}
+
+type checker struct {
+	c    *Context
+	errs []error
+}
+
+type checkScope struct {
+	names  map[string]bool
+	parent *checkScope
+}
+
+func (s *checkScope) defines(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (ch *checker) block(stmts []ast.Statement, parent *checkScope) {
+	scope := &checkScope{names: map[string]bool{}, parent: parent}
+	for _, s := range stmts {
+		if let, ok := s.(*ast.LetStatement); ok {
+			scope.names[let.Name.Value] = true
+		}
+	}
+	for _, s := range stmts {
+		ch.node(s, scope)
+	}
+}
+
+func (ch *checker) node(node ast.Node, scope *checkScope) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		ch.block(node.Statements, scope)
+
+	case *ast.Fn:
+		params := &checkScope{names: map[string]bool{}, parent: scope}
+		for _, p := range node.Params {
+			params.names[p.Value] = true
+		}
+		ch.block(node.Body.Statements, params)
+
+	case *ast.Id:
+		if scope.defines(node.Value) {
+			return
+		}
+		if _, ok := ch.c.scope.Get(node.Value); ok {
+			return
+		}
+		if _, ok := ch.c.builtins[node.Value]; ok {
+			return
+		}
+		if _, ok := ch.c.constants[node.Value]; ok {
+			return
+		}
+		ch.errs = append(ch.errs, &parser.Error{
+			Line: node.Token.Line,
+			Col:  node.Token.StartCol(),
+			Msg:  fmt.Sprintf("identifier not found: %s", node.Value),
+		})
+
+	default:
+		for _, child := range children(node) {
+			ch.node(child, scope)
+		}
+	}
+}
+
+// children returns the nodes right below node, leaving out the names bound by
+// let statements and function parameters.
+func children(node ast.Node) []ast.Node {
+	var nodes []ast.Node
+	add := func(exprs ...ast.Expression) {
+		for _, e := range exprs {
+			if e != nil {
+				nodes = append(nodes, e)
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *ast.Module:
+		for _, s := range node.Statements {
+			nodes = append(nodes, s)
+		}
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			nodes = append(nodes, s)
+		}
+	case *ast.LetStatement:
+		add(node.Value)
+	case *ast.ReturnStatement:
+		add(node.Value)
+	case *ast.ExpressionStatement:
+		add(node.Expression)
+	case *ast.Array:
+		add(node.Elements...)
+	case *ast.Set:
+		add(node.Elements...)
+	case *ast.Tuple:
+		add(node.Elements...)
+	case *ast.PrefixExpression:
+		add(node.Right)
+	case *ast.InfixExpression:
+		add(node.Left, node.Right)
+	case *ast.IfExpression:
+		add(node.Condition)
+		nodes = append(nodes, node.Consequence)
+		if node.Alternative != nil {
+			nodes = append(nodes, node.Alternative)
+		}
+	case *ast.Fn:
+		nodes = append(nodes, node.Body)
+	case *ast.Call:
+		add(node.Fn)
+		add(node.Args...)
+	case *ast.Index:
+		add(node.Left, node.Index)
+	case *ast.Slice:
+		add(node.Left, node.Low, node.High)
+	case *ast.Range:
+		add(node.Start, node.End, node.Step)
+	case *ast.Hash:
+		for _, p := range node.Pairs {
+			add(p.Key, p.Value)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,356 @@
+package eval
+
+import (
+	"sort"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// collectionBuiltins are the eager counterparts of seqBuiltins: they walk the
+// whole collection (or until they have an answer) and call back into geo
+// functions for each element. Like the other builtins, the collection comes
+// last so they can be curried and piped.
+func (c *Context) collectionBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"reduce": &object.Builtin{
+			Name:   "reduce",
+			Doc:    "reduce(f, acc, xs) calls f(acc, x) for each x, with acc the result of the previous call, and returns the last one.",
+			Params: []object.ObjectType{callableTypes, object.TypeAny, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				f, acc := args[0], args[1]
+				it := iterOf(args[2])
+				for {
+					elm, ok := it.Next()
+					if !ok {
+						return acc
+					}
+					if isError(elm) {
+						return elm
+					}
+					acc = c.applyFn(f, acc, elm)
+					if isError(acc) {
+						return acc
+					}
+				}
+			},
+		},
+		"each": &object.Builtin{
+			Name:   "each",
+			Doc:    "each(f, xs) calls f(x) for each x, and returns null.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				if err := c.forEach(args[1], func(elm object.Object) (object.Object, bool) {
+					result := c.applyFn(args[0], elm)
+					return result, !isError(result)
+				}); isError(err) {
+					return err
+				}
+				return Null
+			},
+		},
+		"flat_map": &object.Builtin{
+			Name:   "flat_map",
+			Doc:    "flat_map(f, xs) are the elements of the collections f(x) returns for each x.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				f, coll := args[0], args[1]
+				return lazy(coll, func() object.Iterator {
+					outer := iterOf(coll)
+					inner := elementsIter(nil)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						for {
+							if elm, ok := inner.Next(); ok {
+								return elm, true
+							}
+
+							elm, ok := outer.Next()
+							if !ok || isError(elm) {
+								return elm, ok
+							}
+							sub := c.applyFn(f, elm)
+							if isError(sub) {
+								return sub, true
+							}
+							if sub.Type()&iterableTypes == 0 {
+								return newError("result of `flat_map` function must be (%s), got %s", object.ObjectTypesToString(iterableTypes), sub.Type()), true
+							}
+							inner = iterOf(sub)
+						}
+					})
+				})
+			},
+		},
+		"sort": &object.Builtin{
+			Name:   "sort",
+			Doc:    "sort(xs) is an array of the numbers or strings of xs, sorted.",
+			Params: []object.ObjectType{iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				return c.sortBy(nil, args[0])
+			},
+		},
+		"sort_by": &object.Builtin{
+			Name:   "sort_by",
+			Doc:    "sort_by(f, xs) is an array of the elements of xs, sorted by what f returns for them.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				return c.sortBy(args[0], args[1])
+			},
+		},
+		"group_by": &object.Builtin{
+			Name:   "group_by",
+			Doc:    "group_by(f, xs) is a hash of arrays of the elements of xs, keyed by what f returns for them.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				groups := object.NewHash()
+				err := c.forEach(args[1], func(elm object.Object) (object.Object, bool) {
+					key := c.applyFn(args[0], elm)
+					if isError(key) {
+						return key, false
+					}
+					if _, ok := key.(object.Hashable); !ok {
+						return newError("unusable as hash key: %s", key.Type()), false
+					}
+
+					group, ok := groups.Get(key)
+					if !ok {
+						group = object.NewArray([]object.Object{})
+					}
+					groups = groups.Assoc(key, group.(*object.Array).Push(elm))
+					return nil, true
+				})
+				if isError(err) {
+					return err
+				}
+				return groups
+			},
+		},
+		"find": &object.Builtin{
+			Name:   "find",
+			Doc:    "find(f, xs) is the first x for which f(x) is truthy, or null.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				var found object.Object = Null
+				err := c.forEach(args[1], func(elm object.Object) (object.Object, bool) {
+					match := c.applyFn(args[0], elm)
+					if isError(match) {
+						return match, false
+					}
+					if isTruthy(match) {
+						found = elm
+						return nil, false
+					}
+					return nil, true
+				})
+				if isError(err) {
+					return err
+				}
+				return found
+			},
+		},
+		"any?": &object.Builtin{
+			Name:   "any?",
+			Doc:    "any?(f, xs) tells whether f(x) is truthy for some x.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				return c.quantify(args[0], args[1], true)
+			},
+		},
+		"all?": &object.Builtin{
+			Name:   "all?",
+			Doc:    "all?(f, xs) tells whether f(x) is truthy for every x.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				return c.quantify(args[0], args[1], false)
+			},
+		},
+		"count": &object.Builtin{
+			Name:   "count",
+			Doc:    "count(f, xs) is the number of elements x for which f(x) is truthy.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				n := 0
+				err := c.forEach(args[1], func(elm object.Object) (object.Object, bool) {
+					match := c.applyFn(args[0], elm)
+					if isError(match) {
+						return match, false
+					}
+					if isTruthy(match) {
+						n++
+					}
+					return nil, true
+				})
+				if isError(err) {
+					return err
+				}
+				return object.NewNumber(float64(n))
+			},
+		},
+		"uniq": &object.Builtin{
+			Name:   "uniq",
+			Doc:    "uniq(xs) are the elements of xs without repetitions, in order.",
+			Params: []object.ObjectType{iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				coll := args[0]
+				return lazy(coll, func() object.Iterator {
+					it := iterOf(coll)
+					seen := object.NewSet(nil)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						for {
+							elm, ok := it.Next()
+							if !ok || isError(elm) {
+								return elm, ok
+							}
+							if _, ok := elm.(object.Hashable); !ok {
+								return newError("unusable as set element: %s", elm.Type()), true
+							}
+							if !seen.Has(elm) {
+								seen = seen.Add(elm)
+								return elm, true
+							}
+						}
+					})
+				})
+			},
+		},
+		"reverse": &object.Builtin{
+			Name:   "reverse",
+			Doc:    "reverse(xs) are the elements, or characters of a string, in reverse order.",
+			Params: []object.ObjectType{iterableTypes | object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				if str, ok := args[0].(*object.String); ok {
+					clusters := graphemes(str.Value)
+					var b []byte
+					for i := len(clusters) - 1; i >= 0; i-- {
+						b = append(b, clusters[i]...)
+					}
+					return object.NewString(string(b))
+				}
+
+				result := collect(iterOf(args[0]))
+				if isError(result) {
+					return result
+				}
+				elms := result.(*object.Array).Elements()
+				for i, j := 0, len(elms)-1; i < j; i, j = i+1, j-1 {
+					elms[i], elms[j] = elms[j], elms[i]
+				}
+				return object.NewArray(elms)
+			},
+		},
+		// concat takes the collection being extended last, like the set
+		// operations: `a | concat(b)` are the elements of a then those of b.
+		"concat": &object.Builtin{
+			Name:   "concat",
+			Doc:    "concat(b, a) are the elements of a, then those of b.",
+			Params: []object.ObjectType{iterableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				other, coll := args[0], args[1]
+				chained := func() object.Iterator {
+					first, second := iterOf(coll), iterOf(other)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						if elm, ok := first.Next(); ok {
+							return elm, true
+						}
+						return second.Next()
+					})
+				}
+				if _, ok := other.(*object.Seq); ok {
+					return object.NewSeq(chained)
+				}
+				return lazy(coll, chained)
+			},
+		},
+	}
+}
+
+// forEach calls f for each element of coll until it returns false, and
+// returns the value f stopped with if that was an error, or the first error
+// yielded by coll.
+func (c *Context) forEach(coll object.Object, f func(object.Object) (object.Object, bool)) object.Object {
+	it := iterOf(coll)
+	for {
+		elm, ok := it.Next()
+		if !ok {
+			return nil
+		}
+		if isError(elm) {
+			return elm
+		}
+		if result, ok := f(elm); !ok {
+			return result
+		}
+	}
+}
+
+// quantify answers whether some (or all, when some is false) elements of coll
+// satisfy f, stopping as soon as the answer is known.
+func (c *Context) quantify(f, coll object.Object, some bool) object.Object {
+	answer := !some
+	err := c.forEach(coll, func(elm object.Object) (object.Object, bool) {
+		match := c.applyFn(f, elm)
+		if isError(match) {
+			return match, false
+		}
+		if isTruthy(match) == some {
+			answer = some
+			return nil, false
+		}
+		return nil, true
+	})
+	if isError(err) {
+		return err
+	}
+	return c.nativeBoolToObject(answer)
+}
+
+// sortBy sorts coll into a new array, comparing the keys given by f, or the
+// elements themselves when f is nil. The sort is stable.
+func (c *Context) sortBy(f, coll object.Object) object.Object {
+	result := collect(iterOf(coll))
+	if isError(result) {
+		return result
+	}
+	elms := result.(*object.Array).Elements()
+
+	keys := elms
+	if f != nil {
+		keys = make([]object.Object, len(elms))
+		for i, elm := range elms {
+			keys[i] = c.applyFn(f, elm)
+			if isError(keys[i]) {
+				return keys[i]
+			}
+		}
+	}
+
+	for i := 1; i < len(keys); i++ {
+		if k := keys[i]; k.Type() != keys[0].Type() || k.Type()&(object.TypeNumber|object.TypeString) == 0 {
+			return newError("cannot compare %s and %s", keys[0].Type(), k.Type())
+		}
+	}
+
+	idx := make([]int, len(elms))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return less(keys[idx[i]], keys[idx[j]])
+	})
+
+	sorted := make([]object.Object, len(elms))
+	for i, j := range idx {
+		sorted[i] = elms[j]
+	}
+	return object.NewArray(sorted)
+}
+
+// less compares two numbers or two strings.
+func less(a, b object.Object) bool {
+	switch a := a.(type) {
+	case *object.Number:
+		return a.Value < b.(*object.Number).Value
+	case *object.String:
+		return a.Value < b.(*object.String).Value
+	}
+	return false
+}
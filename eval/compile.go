@@ -1,7 +1,6 @@
 package eval
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/geovanisouza92/geo/ast"
@@ -14,7 +13,7 @@ func Compile(input string) (*ast.Module, error) {
 	p := parser.New(l)
 	m, errs := p.Parse()
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("%v", errs)
+		return nil, errs
 	}
 	return m, nil
 }
@@ -1,19 +1,87 @@
 package eval
 
 import (
+	"bufio"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
 	"github.com/geovanisouza92/geo/ast"
 	"github.com/geovanisouza92/geo/object"
 )
 
 type Context struct {
-	registry *ModuleRegistry
-	builtins map[string]*object.Builtin
-	scope    *object.Scope
+	registry  *ModuleRegistry
+	builtins  map[string]*object.Builtin
+	constants map[string]object.Object
+	scope     *object.Scope
+	rand      *rand.Rand
+	caps      Capabilities
+	stdin     *bufio.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+	precision int
+}
+
+// Capabilities are the outside resources scripts may use. The zero value
+// grants none, so contexts are safe for untrusted scripts unless the host
+// grants more.
+type Capabilities struct {
+	// Roots are the directories scripts may access files in, subdirectories
+	// included. Paths are checked after resolving symbolic links.
+	Roots []string
+
+	// ReadOnly forbids writing to Roots.
+	ReadOnly bool
+
+	// Env allows reading environment variables.
+	Env bool
+}
+
+// Grant sets what the scripts of the context may access.
+func (c *Context) Grant(caps Capabilities) {
+	c.caps = caps
 }
 
 func NewContext(scope *object.Scope) *Context {
-	c := &Context{registry: newModuleRegistry(), scope: scope}
-	c.builtins = builtins
+	c := &Context{
+		registry:  newModuleRegistry(),
+		scope:     scope,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		stdin:     bufio.NewReader(os.Stdin),
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		precision: -1,
+	}
+	// Builtins that call back into the interpreter are bound to the context,
+	// so each context gets its own table
+	c.builtins = map[string]*object.Builtin{}
+	groups := []map[string]*object.Builtin{
+		builtins,
+		stringBuiltins,
+		formatBuiltins,
+		mathBuiltins,
+		jsonBuiltins,
+		c.seqBuiltins(),
+		c.collectionBuiltins(),
+		c.randomBuiltins(),
+		c.fsBuiltins(),
+		c.ioBuiltins(),
+		c.envBuiltins(),
+	}
+	for _, group := range groups {
+		for name, b := range group {
+			c.builtins[name] = b
+		}
+	}
+
+	c.constants = map[string]object.Object{}
+	for name, v := range constants {
+		c.constants[name] = v
+	}
+	c.SetArgs(nil)
 	c.builtins["import"] = &object.Builtin{
 		Impl: func(args ...object.Object) object.Object {
 			// TODO
@@ -24,6 +92,43 @@ func NewContext(scope *object.Scope) *Context {
 	return c
 }
 
+// Seed resets the random numbers of the context, so runs with the same seed
+// draw the same values.
+func (c *Context) Seed(seed int64) {
+	c.rand.Seed(seed)
+}
+
+// SetPrecision sets the number of digits after the decimal point numbers
+// print with, or -1, the default, for as many as needed to read them back.
+// Only printing is affected: str and format keep their own rules.
+func (c *Context) SetPrecision(precision int) {
+	c.precision = precision
+}
+
+// Format prints obj as the context prints values, with its precision.
+func (c *Context) Format(obj object.Object) string {
+	return object.Format(obj, c.precision)
+}
+
+// Builtin looks up the builtin function called name.
+func (c *Context) Builtin(name string) (*object.Builtin, bool) {
+	b, ok := c.builtins[name]
+	return b, ok
+}
+
+// Globals returns the names of the builtins and constants, sorted.
+func (c *Context) Globals() []string {
+	names := make([]string, 0, len(c.builtins)+len(c.constants))
+	for name := range c.builtins {
+		names = append(names, name)
+	}
+	for name := range c.constants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *Context) Eval(m *ast.Module) object.Object {
 	return c.internalEval(m, c.scope)
 }
@@ -0,0 +1,90 @@
+package eval
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// SetArgs sets the args array scripts see, usually the command line
+// arguments meant for them.
+func (c *Context) SetArgs(args []string) {
+	c.constants["args"] = stringArray(args)
+}
+
+// Exit is the error raised by the exit builtin. It stops the evaluation like
+// any error, and hosts can tell it apart with ExitCode.
+type Exit struct {
+	Code int
+}
+
+func (e *Exit) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode tells whether result comes from the exit builtin, and with which
+// code.
+func ExitCode(result object.Object) (int, bool) {
+	if err, ok := result.(*object.Error); ok {
+		if exit, ok := err.Message.(*Exit); ok {
+			return exit.Code, true
+		}
+	}
+	return 0, false
+}
+
+func (c *Context) envBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		// env returns null for variables not set
+		"env": &object.Builtin{
+			Name:   "env",
+			Doc:    "env(name) is the environment variable name, or null when it is not set.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				if !c.caps.Env {
+					return newError("%s: environment", errAccessDenied)
+				}
+				if value, ok := os.LookupEnv(args[0].(*object.String).Value); ok {
+					return object.NewString(value)
+				}
+				return Null
+			},
+		},
+		// env_all returns all variables, sorted by name
+		"env_all": &object.Builtin{
+			Name:   "env_all",
+			Doc:    "env_all() is a hash of the environment variables, sorted by name.",
+			Params: []object.ObjectType{},
+			Impl: func(args ...object.Object) object.Object {
+				if !c.caps.Env {
+					return newError("%s: environment", errAccessDenied)
+				}
+				vars := os.Environ()
+				sort.Strings(vars)
+
+				hash := object.NewHash()
+				for _, v := range vars {
+					if i := strings.IndexByte(v, '='); i > 0 {
+						hash = hash.Assoc(object.NewString(v[:i]), object.NewString(v[i+1:]))
+					}
+				}
+				return hash
+			},
+		},
+		"exit": &object.Builtin{
+			Name:   "exit",
+			Doc:    "exit(code) stops the script, exiting with code.",
+			Params: []object.ObjectType{object.TypeNumber},
+			Impl: func(args ...object.Object) object.Object {
+				code := args[0].(*object.Number).Value
+				if code != float64(int(code)) || code < 0 || code > 255 {
+					return newError("exit code must be an integer in [0, 255], got %s", args[0].String())
+				}
+				return &object.Error{Message: &Exit{Code: int(code)}}
+			},
+		},
+	}
+}
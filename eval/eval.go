@@ -2,9 +2,11 @@ package eval
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/geovanisouza92/geo/ast"
 	"github.com/geovanisouza92/geo/object"
+	"github.com/geovanisouza92/geo/token"
 )
 
 var (
@@ -14,6 +16,46 @@ var (
 )
 
 func (c *Context) internalEval(node ast.Node, scope *object.Scope) object.Object {
+	result := c.evalNode(node, scope)
+	if err, ok := result.(*object.Error); ok && err.Line == 0 {
+		locateError(err, node)
+	}
+	return result
+}
+
+// locateError marks err as raised by node, if node is one that raises errors.
+// The position is the start of the token of the node: the name, operator or
+// bracket involved.
+func locateError(err *object.Error, node ast.Node) {
+	var tok token.Token
+	switch node := node.(type) {
+	case *ast.Id:
+		tok = node.Token
+	case *ast.PrefixExpression:
+		tok = node.Token
+	case *ast.InfixExpression:
+		tok = node.Token
+	case *ast.Call:
+		tok = node.Token
+	case *ast.Index:
+		tok = node.Token
+	case *ast.Slice:
+		tok = node.Token
+	case *ast.Range:
+		tok = node.Token
+	case *ast.Hash:
+		tok = node.Token
+	case *ast.Set:
+		tok = node.Token
+	default:
+		return
+	}
+
+	err.Line = tok.Line
+	err.Col = tok.StartCol()
+}
+
+func (c *Context) evalNode(node ast.Node, scope *object.Scope) object.Object {
 	switch node := node.(type) {
 	case *ast.Number:
 		return object.NewNumber(node.Value)
@@ -29,11 +71,21 @@ func (c *Context) internalEval(node ast.Node, scope *object.Scope) object.Object
 		if len(elms) == 1 && isError(elms[0]) {
 			return elms[0]
 		}
-		return &object.Array{elms}
+		return object.NewArray(elms)
 
 	case *ast.Hash:
 		return c.evalHashExpression(node, scope)
 
+	case *ast.Set:
+		return c.evalSetExpression(node, scope)
+
+	case *ast.Tuple:
+		elms := c.evalExpressions(node.Elements, scope)
+		if len(elms) == 1 && isError(elms[0]) {
+			return elms[0]
+		}
+		return object.NewTuple(elms)
+
 	case *ast.Index:
 		left := c.internalEval(node.Left, scope)
 		if isError(left) {
@@ -45,6 +97,12 @@ func (c *Context) internalEval(node ast.Node, scope *object.Scope) object.Object
 		}
 		return c.evalIndexExpression(left, index)
 
+	case *ast.Slice:
+		return c.evalSliceExpression(node, scope)
+
+	case *ast.Range:
+		return c.evalRangeExpression(node, scope)
+
 	case *ast.PrefixExpression:
 		right := c.internalEval(node.Right, scope)
 		if isError(right) {
@@ -84,7 +142,7 @@ func (c *Context) internalEval(node ast.Node, scope *object.Scope) object.Object
 		if isError(val) {
 			return val
 		}
-		return &object.Return{val}
+		return &object.Return{Value: val}
 
 	case *ast.ExpressionStatement:
 		return c.internalEval(node.Expression, scope)
@@ -187,16 +245,36 @@ func (c *Context) applyFn(fn object.Object, args ...object.Object) object.Object
 		return result
 
 	case *object.Builtin:
-		if len(args) < len(fn.Params) {
-			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		// Builtins with nil params check their args themselves
+		tooFew := len(args) == 0 && len(fn.Params) > 0
+		tooMany := fn.Params != nil && len(args) > len(fn.Params)
+		if tooFew || tooMany {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), len(fn.Params))
 		}
 
 		for i, a := range fn.Params {
-			if a&args[i].Type() == 0 {
+			if i < len(args) && a&args[i].Type() == 0 {
 				return newError("argument to `%s` must be (%s), got %s", fn.Name, object.ObjectTypesToString(a), args[i].Type().String())
 			}
 		}
 
+		// Like functions, builtins given less args than params return a new
+		// builtin waiting for the rest
+		if len(args) < len(fn.Params) {
+			bound := args
+			impl := fn.Impl
+			return &object.Builtin{
+				Name:   fn.Name,
+				Doc:    fn.Doc,
+				Params: fn.Params[len(args):],
+				Impl: func(rest ...object.Object) object.Object {
+					all := make([]object.Object, 0, len(bound)+len(rest))
+					all = append(all, bound...)
+					return impl(append(all, rest...)...)
+				},
+			}
+		}
+
 		return fn.Impl(args...)
 
 	default:
@@ -216,6 +294,12 @@ func (c *Context) evalIndexExpression(left, index object.Object) object.Object {
 	case left.Type() == object.TypeArray && index.Type() == object.TypeNumber:
 		return c.evalArrayIndexExpression(left, index)
 
+	case left.Type() == object.TypeString && index.Type() == object.TypeNumber:
+		return c.evalStringIndexExpression(left, index)
+
+	case left.Type() == object.TypeTuple && index.Type() == object.TypeNumber:
+		return c.evalTupleIndexExpression(left, index)
+
 	case left.Type() == object.TypeHash:
 		return c.evalHashIndexExpression(left, index)
 
@@ -226,25 +310,193 @@ func (c *Context) evalIndexExpression(left, index object.Object) object.Object {
 
 func (c *Context) evalArrayIndexExpression(left, index object.Object) object.Object {
 	ary := left.(*object.Array)
-	max := int64(len(ary.Elements) - 1)
-	idx := int64(index.(*object.Number).Value)
-	if idx < 0 || idx > max {
+	idx, ok := resolveIndex(index, ary.Len())
+	if !ok {
+		return Null
+	}
+	return ary.At(idx)
+}
+
+// evalStringIndexExpression indexes strings by rune, not by byte.
+func (c *Context) evalStringIndexExpression(left, index object.Object) object.Object {
+	str := left.(*object.String).Value
+	idx, ok := resolveIndex(index, runeCount(str))
+	if !ok {
 		return Null
 	}
-	return ary.Elements[idx]
+	return object.NewString(runeSlice(str, idx, idx+1))
+}
+
+func (c *Context) evalTupleIndexExpression(left, index object.Object) object.Object {
+	tuple := left.(*object.Tuple)
+	idx, ok := resolveIndex(index, tuple.Len())
+	if !ok {
+		return Null
+	}
+	return tuple.At(idx)
+}
+
+// resolveIndex counts negative indexes from the end, so -1 is the last
+// element, and reports whether the index falls inside a sequence of length n.
+func resolveIndex(index object.Object, n int) (int, bool) {
+	idx := int(index.(*object.Number).Value)
+	if idx < 0 {
+		idx += n
+	}
+	return idx, idx >= 0 && idx < n
+}
+
+func (c *Context) evalSliceExpression(node *ast.Slice, scope *object.Scope) object.Object {
+	left := c.internalEval(node.Left, scope)
+	if isError(left) {
+		return left
+	}
+
+	var n int
+	switch left := left.(type) {
+	case *object.Array:
+		n = left.Len()
+	case *object.String:
+		n = runeCount(left.Value)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	lo, err := c.evalSliceBound(node.Low, 0, scope)
+	if err != nil {
+		return err
+	}
+	hi, err := c.evalSliceBound(node.High, n, scope)
+	if err != nil {
+		return err
+	}
+
+	return sliceOf(left, lo, hi)
+}
+
+// evalSliceBound evaluates one of the bounds of a slice, using def when it
+// was omitted.
+func (c *Context) evalSliceBound(exp ast.Expression, def int, scope *object.Scope) (int, object.Object) {
+	if exp == nil {
+		return def, nil
+	}
+
+	bound := c.internalEval(exp, scope)
+	if isError(bound) {
+		return 0, bound
+	}
+
+	num, ok := bound.(*object.Number)
+	if !ok {
+		return 0, newError("slice bound must be TypeNumber, got %s", bound.Type())
+	}
+	i, ok := toInt(num.Value)
+	if !ok {
+		return 0, newError("slice bound must not be nan")
+	}
+	return i, nil
+}
+
+// sliceOf returns the elements (or runes) of coll in [lo, hi). Negative
+// bounds count from the end and bounds past either end are clamped; an empty
+// result is returned when lo >= hi.
+func sliceOf(coll object.Object, lo, hi int) object.Object {
+	switch coll := coll.(type) {
+	case *object.Array:
+		lo, hi = sliceBounds(lo, hi, coll.Len())
+		return coll.Slice(lo, hi)
+
+	case *object.String:
+		lo, hi = sliceBounds(lo, hi, runeCount(coll.Value))
+		return object.NewString(runeSlice(coll.Value, lo, hi))
+
+	default:
+		return newError("slice operator not supported: %s", coll.Type())
+	}
+}
+
+// maxInt and minInt are the ends of the int range, which toInt clamps to.
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// toInt truncates v to an int, clamping values past the ends of the int
+// range, infinities included, to those ends. It fails for nan.
+func toInt(v float64) (int, bool) {
+	switch {
+	case math.IsNaN(v):
+		return 0, false
+	case v >= float64(maxInt):
+		return maxInt, true
+	case v <= float64(minInt):
+		return minInt, true
+	default:
+		return int(v), true
+	}
+}
+
+func sliceBounds(lo, hi, n int) (int, int) {
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		switch {
+		case i < 0:
+			return 0
+		case i > n:
+			return n
+		default:
+			return i
+		}
+	}
+
+	lo, hi = clamp(lo), clamp(hi)
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
+
+func (c *Context) evalRangeExpression(node *ast.Range, scope *object.Scope) object.Object {
+	bounds := []ast.Expression{node.Start, node.End}
+	if node.Step != nil {
+		bounds = append(bounds, node.Step)
+	}
+
+	values := []float64{}
+	for _, exp := range bounds {
+		v := c.internalEval(exp, scope)
+		if isError(v) {
+			return v
+		}
+		num, ok := v.(*object.Number)
+		if !ok {
+			return newError("range bound must be TypeNumber, got %s", v.Type())
+		}
+		values = append(values, num.Value)
+	}
+
+	step := 1.0
+	if node.Step != nil {
+		step = values[2]
+	}
+	if step == 0 {
+		return newError("range step must not be zero")
+	}
+
+	return object.NewRange(values[0], values[1], step, node.Inclusive)
 }
 
 func (c *Context) evalHashIndexExpression(left, index object.Object) object.Object {
 	hash := left.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
+	if _, ok := index.(object.Hashable); !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hash.Pairs[key.HashKey()]
-	if ok {
-		return pair.Value
+	if val, ok := hash.Get(index); ok {
+		return val
 	}
 
 	return Null
@@ -300,11 +552,14 @@ func (c *Context) evalInfix(op string, left, right object.Object) object.Object
 	case left.Type() == object.TypeString && right.Type() == object.TypeString:
 		return c.evalStringExpression(op, left, right)
 
+	case op == "*" && left.Type() == object.TypeString && right.Type() == object.TypeNumber:
+		return repeatString(left.(*object.String).Value, right.(*object.Number).Value)
+
 	case op == "==":
-		return c.nativeBoolToObject(left == right)
+		return c.nativeBoolToObject(object.Equal(left, right))
 
 	case op == "!=":
-		return c.nativeBoolToObject(left != right)
+		return c.nativeBoolToObject(!object.Equal(left, right))
 
 	case op == "|":
 		return c.applyFn(right, left)
@@ -365,7 +620,11 @@ func (c *Context) evalStringExpression(op string, left, right object.Object) obj
 	case "+":
 		return object.NewString(leftVal + rightVal)
 
-	// NOTE: Maybe... case "*": return object.NewNumber(leftVal * rightVal)
+	case "==":
+		return c.nativeBoolToObject(leftVal == rightVal)
+
+	case "!=":
+		return c.nativeBoolToObject(leftVal != rightVal)
 
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
@@ -373,28 +632,46 @@ func (c *Context) evalStringExpression(op string, left, right object.Object) obj
 }
 
 func (c *Context) evalHashExpression(node *ast.Hash, scope *object.Scope) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for k, v := range node.Pairs {
-		key := c.internalEval(k, scope)
+	for _, p := range node.Pairs {
+		key := c.internalEval(p.Key, scope)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := key.(object.Hashable); !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := c.internalEval(v, scope)
+		value := c.internalEval(p.Value, scope)
 		if isError(value) {
 			return value
 		}
 
-		pairs[hashKey.HashKey()] = object.HashPair{key, value}
+		hash = hash.Assoc(key, value)
 	}
 
-	return &object.Hash{pairs}
+	return hash
+}
+
+func (c *Context) evalSetExpression(node *ast.Set, scope *object.Scope) object.Object {
+	set := object.NewSet(nil)
+
+	for _, e := range node.Elements {
+		elm := c.internalEval(e, scope)
+		if isError(elm) {
+			return elm
+		}
+
+		if _, ok := elm.(object.Hashable); !ok {
+			return newError("unusable as set element: %s", elm.Type())
+		}
+
+		set = set.Add(elm)
+	}
+
+	return set
 }
 
 func (c *Context) evalIdExpression(node *ast.Id, scope *object.Scope) object.Object {
@@ -404,6 +681,9 @@ func (c *Context) evalIdExpression(node *ast.Id, scope *object.Scope) object.Obj
 	if builtin, ok := c.builtins[node.Value]; ok {
 		return builtin
 	}
+	if constant, ok := c.constants[node.Value]; ok {
+		return constant
+	}
 	return newError("identifier not found: %s", node.Value)
 }
 
@@ -436,10 +716,16 @@ func isTruthy(v object.Object) bool {
 		if str, ok := v.(*object.String); ok && str.Value == "" {
 			return false
 		}
-		if ary, ok := v.(*object.Array); ok && len(ary.Elements) == 0 {
+		if ary, ok := v.(*object.Array); ok && ary.Len() == 0 {
+			return false
+		}
+		if hsh, ok := v.(*object.Hash); ok && hsh.Len() == 0 {
+			return false
+		}
+		if set, ok := v.(*object.Set); ok && set.Len() == 0 {
 			return false
 		}
-		if hsh, ok := v.(*object.Hash); ok && len(hsh.Pairs) == 0 {
+		if tup, ok := v.(*object.Tuple); ok && tup.Len() == 0 {
 			return false
 		}
 	}
@@ -447,12 +733,12 @@ func isTruthy(v object.Object) bool {
 }
 
 func newError(msg string, a ...interface{}) object.Object {
-	return &object.Error{fmt.Errorf(msg, a...)}
+	return &object.Error{Message: fmt.Errorf(msg, a...)}
 }
 
 func isError(obj object.Object) bool {
 	if obj != nil {
-		return obj.Type() == object.TypeNull
+		return obj.Type() == object.TypeError
 	}
 	return false
 }
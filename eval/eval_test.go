@@ -1,7 +1,12 @@
 package eval
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/geovanisouza92/geo/object"
@@ -53,6 +58,22 @@ func TestEval(t *testing.T) {
 			{"(1 < 2) == false", false},
 			{"(1 > 2) == true", false},
 			{"(1 > 2) == false", true},
+			{"[1, 2] == [1, 2]", true},
+			{"[1, 2] != [1, 2]", false},
+			{"[1, 2] == [2, 1]", false},
+			{"[1, 2] == [1, 2, 3]", false},
+			{"[1, [2, 3]] == [1, [2, 3]]", true},
+			{"[1, [2, 3]] == [1, [2, 4]]", false},
+			{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+			{`{"a": 1} == {"a": 2}`, false},
+			{`{"a": 1} == {"b": 1}`, false},
+			{`{"a": [1, {"b": 2}]} == {"a": [1, {"b": 2}]}`, true},
+			{`[1] == {}`, false},
+			{`let a = [1]; identical?(a, a)`, true},
+			{`identical?([1], [1])`, false},
+			{`"foo" == "foo"`, true},
+			{`"foo" != "foo"`, false},
+			{`"foo" == "bar"`, false},
 		}
 
 		for _, tc := range tt {
@@ -85,12 +106,12 @@ func TestEval(t *testing.T) {
 		if !ok {
 			t.Errorf("value should be *object.Array; got %T", actual)
 		}
-		if len(ary.Elements) != 3 {
-			t.Errorf("array should have 3 elements; got %d", len(ary.Elements))
+		if ary.Len() != 3 {
+			t.Errorf("array should have 3 elements; got %d", ary.Len())
 		}
-		testNumber(t, ary.Elements[0], 1)
-		testNumber(t, ary.Elements[1], 4)
-		testNumber(t, ary.Elements[2], 6)
+		testNumber(t, ary.At(0), 1)
+		testNumber(t, ary.At(1), 4)
+		testNumber(t, ary.At(2), 6)
 	})
 
 	t.Run("hash", func(t *testing.T) {
@@ -104,13 +125,16 @@ func TestEval(t *testing.T) {
 					false: 6,
 				}`
 
-		expected := map[object.HashKey]float64{
-			(object.NewString("one").HashKey()):   1,
-			(object.NewString("two").HashKey()):   2,
-			(object.NewString("three").HashKey()): 3,
-			(object.NewNumber(4).HashKey()):       4,
-			(True.HashKey()):                      5,
-			(False.HashKey()):                     6,
+		expected := []struct {
+			key object.Object
+			val float64
+		}{
+			{object.NewString("one"), 1},
+			{object.NewString("two"), 2},
+			{object.NewString("three"), 3},
+			{object.NewNumber(4), 4},
+			{True, 5},
+			{False, 6},
 		}
 
 		actual := testEval(t, input)
@@ -118,17 +142,38 @@ func TestEval(t *testing.T) {
 		if !ok {
 			t.Errorf("value should be *object.Hash; got %T", actual)
 		}
-		if len(hash.Pairs) != len(expected) {
-			t.Errorf("hash should have %d pairs; got %d", len(expected), len(hash.Pairs))
+		if hash.Len() != len(expected) {
+			t.Errorf("hash should have %d pairs; got %d", len(expected), hash.Len())
 		}
 
-		for k, v := range expected {
-			t.Run(fmt.Sprintf("testing %v", v), func(t *testing.T) {
-				pair, ok := hash.Pairs[k]
+		for _, tc := range expected {
+			t.Run(fmt.Sprintf("testing %v", tc.val), func(t *testing.T) {
+				val, ok := hash.Get(tc.key)
 				if !ok {
-					t.Errorf("hash value for key %q should exist", k)
+					t.Errorf("hash value for key %v should exist", tc.key)
+				}
+				testNumber(t, val, tc.val)
+			})
+		}
+	})
+
+	t.Run("hash order", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`{"c": 1, "a": 2, "b": 3}`, "{c: 1, a: 2, b: 3}"},
+			{`{3: "c", 1: "a", 2: "b"}`, "{3: c, 1: a, 2: b}"},
+			{`{"b": 1, "a": 2, "b": 3}`, "{b: 3, a: 2}"},
+			{`{[2, 1]: 1, [1, 2]: {"z": 1, "y": 2}}`, "{[2, 1]: 1, [1, 2]: {z: 1, y: 2}}"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("hash should print as %q; got %q", tc.output, actual.String())
 				}
-				testNumber(t, pair.Value, v)
 			})
 		}
 	})
@@ -147,7 +192,9 @@ func TestEval(t *testing.T) {
 			{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
 			{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
 			{"[1, 2, 3][3]", nil},
-			{"[1, 2, 3][-1]", nil}, // NOTE: len + index
+			{"[1, 2, 3][-1]", 3},
+			{"[1, 2, 3][-3]", 1},
+			{"[1, 2, 3][-4]", nil},
 			{`{"foo": 5}["foo"]`, 5},
 			{`{"foo": 5}["bar"]`, nil},
 			{`let key = "foo"; {"foo": 5}[key]`, 5},
@@ -155,6 +202,14 @@ func TestEval(t *testing.T) {
 			{`{5: 5}[5]`, 5},
 			{`{true: 5}[true]`, 5},
 			{`{false: 5}[false]`, 5},
+			{`{1: 1, 1.5: 2}[1]`, 1},
+			{`{1: 1, 1.5: 2}[1.5]`, 2},
+			{`{[1, 2]: 5}[[1, 2]]`, 5},
+			{`{[1, 2]: 5}[[2, 1]]`, nil},
+			{`let x = 1; let y = 2; {[x, y]: 5}[[1, 2]]`, 5},
+			{`{[1, [2]]: 5}[[1, [2]]]`, 5},
+			{`{{"a": 1, "b": 2}: 5}[{"b": 2, "a": 1}]`, 5},
+			{`{[1, 2]: 5, [1, 2]: 6}[[1, 2]]`, 6},
 		}
 
 		for _, tc := range tt {
@@ -334,6 +389,14 @@ func TestEval(t *testing.T) {
 				`{"name": "Monkey"}[fn(x) { x }]`,
 				"unusable as hash key: TypeFn",
 			},
+			{
+				"-len(1)",
+				"argument to `len` must be (TypeString, TypeArray, TypeSet, TypeTuple), got TypeNumber",
+			},
+			{
+				"[1, foobar, 3]",
+				"identifier not found: foobar",
+			},
 		}
 
 		for _, tc := range tt {
@@ -432,8 +495,8 @@ func TestEval(t *testing.T) {
 			{`len("")`, 0},
 			{`len("four")`, 4},
 			{`len("hello world")`, 11},
-			{`len(1)`, "argument to `len` must be (TypeString, TypeArray), got TypeNumber"},
-			// {`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+			{`len(1)`, "argument to `len` must be (TypeString, TypeArray, TypeSet, TypeTuple), got TypeNumber"},
+			{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 			{`len([1, 2, 3])`, 3},
 			{`len([])`, 0},
 			{`puts!("hello", "world!")`, nil},
@@ -445,7 +508,8 @@ func TestEval(t *testing.T) {
 			{`last(1)`, "argument to `last` must be (TypeArray), got TypeNumber"},
 			{`tail([1, 2, 3])`, []int{2, 3}},
 			{`tail([])`, nil},
-			{`push([], 1)`, []int{1}},
+			{`push(1, [])`, []int{1}},
+			{`[1] | push(2) | push(3)`, []int{1, 2, 3}},
 			{`push(1, 1)`, "argument to `push` must be (TypeArray), got TypeNumber"},
 		}
 
@@ -462,12 +526,12 @@ func TestEval(t *testing.T) {
 					if !ok {
 						t.Errorf("value should be *object.Array; got %T", actual)
 					}
-					if len(ary.Elements) != len(val) {
-						t.Errorf("array should have %d elements; got %d", len(val), len(ary.Elements))
+					if ary.Len() != len(val) {
+						t.Errorf("array should have %d elements; got %d", len(val), ary.Len())
 					}
 					for i, it := range val {
 						t.Run(fmt.Sprintf("checking for value: %v", it), func(t *testing.T) {
-							testNumber(t, ary.Elements[i], float64(it))
+							testNumber(t, ary.At(i), float64(it))
 						})
 					}
 				case float64:
@@ -485,6 +549,904 @@ func TestEval(t *testing.T) {
 		}
 	})
 
+	t.Run("persistent collections", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`assoc(0, 3, [1, 2])`, "[3, 2]"},
+			{`assoc(2, 3, [1, 2])`, "[1, 2, 3]"},
+			{`assoc(3, 3, [1, 2])`, "array index out of range: 3"},
+			{`{"a": 1} | assoc("b", 2)`, "{a: 1, b: 2}"},
+			{`assoc("a", 2, {"a": 1})`, "{a: 2}"},
+			{`assoc(fn(x) { x }, 2, {})`, "unusable as hash key: TypeFn"},
+			{`{"a": 1, "b": 2} | dissoc("a")`, "{b: 2}"},
+			{`dissoc("b", {"a": 1})`, "{a: 1}"},
+			{`let a = [1, 2]; let b = push(3, a); a`, "[1, 2]"},
+			{`let a = [1, 2, 3]; let b = assoc(0, 5, tail(a)); [a, b]`, "[[1, 2, 3], [5, 3]]"},
+			{`let h = {"a": 1}; let g = assoc("b", 2, h); let f = dissoc("a", g); [h, g, f]`, "[{a: 1}, {a: 1, b: 2}, {b: 2}]"},
+			{`assoc([1], 0)`, "builtin function"},
+			{`assoc(0, 1, [1], 2)`, "wrong number of arguments. got=4, want=3"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("sets and tuples", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`#{}`, "#{}"},
+			{`#{3, 1, 2, 1}`, "#{3, 1, 2}"},
+			{`#{[1, 2], [1, 2], (1, 2)}`, "#{[1, 2], (1, 2)}"},
+			{`#{fn(x) { x }}`, "unusable as set element: TypeFn"},
+			{`set([1, 2, 1])`, "#{1, 2}"},
+			{`set((1, 1))`, "#{1}"},
+			{`len(#{1, 2, 2})`, "2"},
+			{`#{1, 2} == #{2, 1}`, "true"},
+			{`#{1, 2} == #{1}`, "false"},
+			{`union(#{3, 1}, #{1, 2})`, "#{1, 2, 3}"},
+			{`intersection(#{3, 1}, #{1, 2})`, "#{1}"},
+			{`difference(#{1}, #{1, 2})`, "#{2}"},
+			{`member?(1, #{1, 2})`, "true"},
+			{`member?(3, #{1, 2})`, "false"},
+			{`member?([1], #{[1]})`, "true"},
+			{`{#{1, 2}: "a"}[#{2, 1}]`, "a"},
+			{`!#{}`, "true"},
+			{`()`, "()"},
+			{`(1,)`, "(1,)"},
+			{`(1, "a", [2])`, "(1, a, [2])"},
+			{`(1, 2)[1]`, "2"},
+			{`(1, 2)[2]`, "null"},
+			{`len((1, 2, 3))`, "3"},
+			{`(1, 2) == (1, 2)`, "true"},
+			{`(1, 2) == [1, 2]`, "false"},
+			{`{(1, 2): "a"}[(1, 2)]`, "a"},
+			{`len(1)`, "argument to `len` must be (TypeString, TypeArray, TypeSet, TypeTuple), got TypeNumber"},
+			{`union([1], #{1})`, "argument to `union` must be (TypeSet), got TypeArray"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`[1, 2, 3, 4][1:3]`, "[2, 3]"},
+			{`[1, 2, 3, 4][:2]`, "[1, 2]"},
+			{`[1, 2, 3, 4][2:]`, "[3, 4]"},
+			{`[1, 2, 3, 4][:]`, "[1, 2, 3, 4]"},
+			{`[1, 2, 3, 4][-2:]`, "[3, 4]"},
+			{`[1, 2, 3, 4][:-1]`, "[1, 2, 3]"},
+			{`[1, 2, 3, 4][-3:-1]`, "[2, 3]"},
+			{`[1, 2, 3, 4][3:1]`, "[]"},
+			{`[1, 2, 3, 4][2:10]`, "[3, 4]"},
+			{`[1, 2, 3, 4][10:]`, "[]"},
+			{`[1, 2, 3, 4][-10:2]`, "[1, 2]"},
+			{`let n = 1; [1, 2, 3, 4][n:n + 2]`, "[2, 3]"},
+			{`let xs = [1, 2, 3, 4]; xs[:2] | push(5)`, "[1, 2, 5]"},
+			{`let xs = [1, 2, 3, 4]; let ys = push(5, xs[:2]); xs`, "[1, 2, 3, 4]"},
+			{`"héllo"[1:3]`, "él"},
+			{`"héllo"[-3:]`, "llo"},
+			{`"héllo"[:-4]`, "h"},
+			{`"世界"[-1]`, "界"},
+			{`{"a": 1}[0:1]`, "slice operator not supported: TypeHash"},
+			{`[1, 2]["a":]`, "slice bound must be TypeNumber, got TypeString"},
+			{`[1, 2, 3][:1 / 0]`, "[1, 2, 3]"},
+			{`[1, 2, 3][-1 / 0:-1]`, "[1, 2]"},
+			{`"abc"[1e300:]`, ""},
+			{`[1, 2][nan:]`, "slice bound must not be nan"},
+			{`[1, 2, 3] | slice(1, 1 / 0)`, "[2, 3]"},
+			{`"abc" | slice(nan, 1)`, "slice bound must not be nan"},
+			{`slice(-2, 10, [1, 2, 3])`, "[2, 3]"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("ranges and seqs", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`1..5`, "1..5"},
+			{`array(1..5)`, "[1, 2, 3, 4, 5]"},
+			{`array(0..<5)`, "[0, 1, 2, 3, 4]"},
+			{`array(1..10 step 3)`, "[1, 4, 7, 10]"},
+			{`array(5..1 step -2)`, "[5, 3, 1]"},
+			{`array(0..1 step 0.25)`, "[0, 0.25, 0.5, 0.75, 1]"},
+			{`array(5..1)`, "[]"},
+			{`array(1..<1)`, "[]"},
+			{`let n = 3; array(0..<n * 2)`, "[0, 1, 2, 3, 4, 5]"},
+			{`1..10 step 0`, "range step must not be zero"},
+			{`1.."a"`, "range bound must be TypeNumber, got TypeString"},
+			{`map(fn(x) { x * 2 }, 1..3)`, "<seq>"},
+			{`array(map(fn(x) { x * 2 }, 1..3))`, "[2, 4, 6]"},
+			{`map(fn(x) { x * 2 }, [1, 2, 3])`, "[2, 4, 6]"},
+			{`filter(fn(x) { x > 1 }, (1, 2, 3))`, "[2, 3]"},
+			{`1..10 | filter(fn(x) { x > 3 }) | take(2) | array`, "[4, 5]"},
+			{`1..5 | drop(3) | array`, "[4, 5]"},
+			{`[1, 2] | drop(5)`, "[]"},
+			{`take(2, [1, 2, 3])`, "[1, 2]"},
+			{`take(1 / 0, [1, 2, 3])`, "[1, 2, 3]"},
+			{`take(-1 / 0, [1, 2, 3])`, "[]"},
+			{`drop(1 / 0, [1, 2, 3])`, "[]"},
+			{`drop(-1 / 0, [1, 2, 3])`, "[1, 2, 3]"},
+			{`take(nan, [1, 2, 3])`, "argument to `take` must not be nan"},
+			{`drop(nan, [1, 2, 3])`, "argument to `drop` must not be nan"},
+			{`zip([1, 2, 3], ["a", "b"])`, `[(1, a), (2, b)]`},
+			{`zip(1..3, [4, 5, 6]) | array`, `[(1, 4), (2, 5), (3, 6)]`},
+			{`let s = map(fn(x) { x + 1 }, 1..3); array(s) == array(s)`, "true"},
+			{`let s = 1..5 | drop(2); push(len(array(s)), array(s))`, "[3, 4, 5, 3]"},
+			{`1..1000000000 | map(fn(x) { x * x }) | take(3) | array`, "[1, 4, 9]"},
+			{`1..3 | map(fn(x) { x + "a" }) | array`, "type mismatch: TypeNumber + TypeString"},
+			{`[1, 2] | filter(fn(x) { -"a" })`, "unknown operator: -TypeString"},
+			{`map(len)([[1], [2, 3]])`, "[1, 2]"},
+			{`if (1..0) { "yes" }`, "yes"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("collection builtins", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`reduce(fn(acc, x) { acc + x }, 0, [1, 2, 3])`, "6"},
+			{`1..100 | reduce(fn(acc, x) { acc + x }, 0)`, "5050"},
+			{`let sum = reduce(fn(acc, x) { acc + x }, 0); sum((1, 2)) + sum(#{3})`, "6"},
+			{`reduce(fn(acc, x) { acc + x }, "", [])`, ""},
+			{`reduce(fn(acc, x) { acc + x }, 0, [1, "a"])`, "type mismatch: TypeNumber + TypeString"},
+			{`each(fn(x) { x }, [1, 2])`, "null"},
+			{`each(fn(x) { -x }, ["a"])`, "unknown operator: -TypeString"},
+			{`flat_map(fn(x) { [x, x * 10] }, [1, 2])`, "[1, 10, 2, 20]"},
+			{`flat_map(fn(x) { 0..<x }, [1, 0, 2])`, "[0, 0, 1]"},
+			{`1..3 | flat_map(fn(x) { (x,) }) | take(2) | array`, "[1, 2]"},
+			{`flat_map(fn(x) { x }, [1])`, "result of `flat_map` function must be (TypeArray, TypeSet, TypeTuple, TypeSeq), got TypeNumber"},
+			{`sort([3, 1, 2])`, "[1, 2, 3]"},
+			{`sort(#{"b", "c", "a"})`, "[a, b, c]"},
+			{`sort([])`, "[]"},
+			{`sort([1, "a"])`, "cannot compare TypeNumber and TypeString"},
+			{`sort([[1], [2]])`, "cannot compare TypeArray and TypeArray"},
+			{`["bb", "a", "cc", "d"] | sort_by(len)`, "[a, d, bb, cc]"},
+			{`sort_by(fn(x) { -x }, 1..4)`, "[4, 3, 2, 1]"},
+			{`group_by(fn(x) { x > 2 }, [1, 3, 2, 4])`, "{false: [1, 2], true: [3, 4]}"},
+			{`group_by(len, ["a", "bb", "c"])`, "{1: [a, c], 2: [bb]}"},
+			{`group_by(fn(x) { fn() {} }, [1])`, "unusable as hash key: TypeFn"},
+			{`find(fn(x) { x > 1 }, [1, 2, 3])`, "2"},
+			{`find(fn(x) { x > 5 }, [1, 2, 3])`, "null"},
+			{`1..1000000000 | find(fn(x) { x * x > 50 })`, "8"},
+			{`any?(fn(x) { x > 2 }, [1, 2, 3])`, "true"},
+			{`any?(fn(x) { x > 2 }, [])`, "false"},
+			{`all?(fn(x) { x > 0 }, [1, 2, 3])`, "true"},
+			{`all?(fn(x) { x > 1 }, 1..1000000000)`, "false"},
+			{`all?(fn(x) { x > 1 }, [])`, "true"},
+			{`count(fn(x) { x > 1 }, [1, 2, 3])`, "2"},
+			{`uniq([1, 2, 1, [3], 2, [3]])`, "[1, 2, [3]]"},
+			{`1..10 | map(fn(x) { x / x }) | uniq | array`, "[1]"},
+			{`uniq([len])`, "unusable as set element: TypeBuiltin"},
+			{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+			{`reverse(1..3)`, "[3, 2, 1]"},
+			{`reverse("héllo")`, "olléh"},
+			{`concat([3], [1, 2])`, "[1, 2, 3]"},
+			{`[1, 2] | concat(3..4)`, "<seq>"},
+			{`[1, 2] | concat(3..4) | array`, "[1, 2, 3, 4]"},
+			{`1..2 | concat((3,)) | array`, "[1, 2, 3]"},
+			{`[1, 2, 3, 4] | filter(fn(x) { x > 1 }) | map(fn(x) { x * x }) | reduce(fn(a, b) { a + b }, 0)`, "29"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("hash builtins", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`keys({"b": 1, "a": 2})`, "[b, a]"},
+			{`values({"b": 1, "a": 2})`, "[1, 2]"},
+			{`entries({"b": 1, "a": 2})`, "[(b, 1), (a, 2)]"},
+			{`keys({})`, "[]"},
+			{`has?("a", {"a": false})`, "true"},
+			{`{"a": 1} | has?("b")`, "false"},
+			{`has?([1], {[1]: 2})`, "true"},
+			{`get("a", {"a": 1})`, "1"},
+			{`get("b", {"a": 1})`, "null"},
+			{`get(fn() {}, {"a": 1})`, "null"},
+			{`{"a": 1} | get_or("b", 0)`, "0"},
+			{`{"a": 1} | get_or("a", 0)`, "1"},
+			{`get("a", {}, 3)`, "wrong number of arguments. got=3, want=2"},
+			{`{"a": 1} | put("b", 2) | put("a", 3)`, "{a: 3, b: 2}"},
+			{`let h = {"a": 1}; let g = put("a", 2, h); h`, "{a: 1}"},
+			{`put(fn() {}, 1, {})`, "unusable as hash key: TypeFn"},
+			{`{"a": 1, "b": 2} | delete("a")`, "{b: 2}"},
+			{`{"a": 1} | delete("z")`, "{a: 1}"},
+			{`[1] | put(1, 2)`, "[1, 2]"},
+			{`{"a": 1, "b": 2} | merge({"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+			{`{"a": {"x": 1, "y": 2}} | merge({"a": {"y": 3}})`, "{a: {y: 3}}"},
+			{`{"a": {"x": 1, "y": 2}, "b": 1} | deep_merge({"a": {"y": 3}, "b": {"z": 1}})`, "{a: {x: 1, y: 3}, b: {z: 1}}"},
+			{`{"a": 1, "b": 2, "c": 3} | select_keys(["c", "a", "z"])`, "{c: 3, a: 1}"},
+			{`{"a": 1, "b": 2} | select_keys(#{"b"})`, "{b: 2}"},
+			{`from_entries([("a", 1), ["b", 2]])`, "{a: 1, b: 2}"},
+			{`{"a": 1, "b": 2} | entries | map(fn(e) { (e[0], e[1] * 10) }) | from_entries`, "{a: 10, b: 20}"},
+			{`from_entries(zip(["a", "b"], 1..2))`, "{a: 1, b: 2}"},
+			{`from_entries([1])`, "hash entry must be a pair, got 1"},
+			{`from_entries([(1, 2, 3)])`, "hash entry must be a pair, got (1, 2, 3)"},
+			{`from_entries([(fn() {}, 2)])`, "unusable as hash key: TypeFn"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("string builtins", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`split(",", "a,b,,c")`, "[a, b, , c]"},
+			{`split("", "héllo")`, "[h, é, l, l, o]"},
+			{`"a b" | split(" ") | join("-")`, "a-b"},
+			{`join(", ", [1, "a", true])`, "1, a, true"},
+			{`join(",", 1..3)`, "1,2,3"},
+			{`join(",", [])`, ""},
+			{`"  hi  " | trim`, "hi"},
+			{`upper("straße")`, "STRASSE"},
+			{`lower("ÀB")`, "àb"},
+			{`"a.b.c" | replace(".", "/")`, "a/b/c"},
+			{`"hello" | contains?("ell")`, "true"},
+			{`"hello" | contains?("xyz")`, "false"},
+			{`"hello" | starts_with?("he")`, "true"},
+			{`"hello" | ends_with?("he")`, "false"},
+			{`"héllo" | index_of("l")`, "2"},
+			{`"hello" | index_of("z")`, "-1"},
+			{`repeat(3, "ab")`, "ababab"},
+			{`"ab" * 2`, "abab"},
+			{`"ab" * 0`, ""},
+			{`"ab" * -1`, "repeat count must be a non-negative integer, got -1"},
+			{`"ab" * 1.5`, "repeat count must be a non-negative integer, got 1.5"},
+			{`"ab" * 1e18`, "repeat count must be at most 268435456, got 1000000000000000000"},
+			{`"a" * 1e12`, "repeat count must be at most 268435456, got 1000000000000"},
+			{`"ab" * 2e8`, "string would be longer than 268435456 bytes"},
+			{`"" * 1e18`, "repeat count must be at most 268435456, got 1000000000000000000"},
+			{`"" * 3`, ""},
+			{`"" * (1 / 0)`, "repeat count must be a non-negative integer, got inf"},
+			{`repeat(1 / 0, "")`, "repeat count must be a non-negative integer, got inf"},
+			{`repeat(-1 / 0, "ab")`, "repeat count must be a non-negative integer, got -inf"},
+			{`"ab" * nan`, "repeat count must be a non-negative integer, got nan"},
+			{`2 * "ab"`, "type mismatch: TypeNumber * TypeString"},
+			{`pad_left(5, "0", "42")`, "00042"},
+			{`pad_left(6, "ab", "é")`, "ababaé"},
+			{`pad_right(4, ".", "héé")`, "héé."},
+			{`pad_right(2, ".", "long")`, "long"},
+			{`pad_left(3, "", "a")`, "padding must not be empty"},
+			{`pad_left(1e18, "x", "a")`, "string would be longer than 268435456 bytes"},
+			{`pad_right(1e300, "x", "a")`, "string would be longer than 268435456 bytes"},
+			{`pad_left(1 / 0, "x", "a")`, "padding width must be finite, got inf"},
+			{`pad_right(-1 / 0, "x", "")`, "padding width must be finite, got -inf"},
+			{`pad_right(nan, "x", "a")`, "padding width must be finite, got nan"},
+			{`lines("")`, "[]"},
+			{`lines("a")`, "[a]"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+
+		// String literals can't hold line breaks
+		for input, output := range map[string]string{
+			"a\nb\n":   "[a, b]",
+			"a\r\nb":   "[a, b]",
+			"a\n\nb":   "[a, , b]",
+			"\n":       "[]",
+			"a\nb\n\n": "[a, b, ]",
+		} {
+			actual := stringBuiltins["lines"].Impl(object.NewString(input))
+			if actual.String() != output {
+				t.Errorf("lines(%q) should be %q; got %q", input, output, actual.String())
+			}
+		}
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`1000000`, "1000000"},
+			{`0.1 + 0.2`, "0.30000000000000004"},
+			{`[1000000, 2.5]`, "[1000000, 2.5]"},
+			{`format("%s is %d", "x", 42)`, "x is 42"},
+			{`format("[%5s|%-5s]", "ab", "cd")`, "[   ab|cd   ]"},
+			{`format("%.2f", 3.14159)`, "3.14"},
+			{`format("%8.3f|", 2)`, "   2.000|"},
+			{`format("%05d", -42)`, "-0042"},
+			{`format("%+d", 5)`, "+5"},
+			{`format("%x", 255)`, "ff"},
+			{`format("%e", 1234.5)`, "1.234500e+03"},
+			{`format("%.2s", "héllo")`, "hé"},
+			{`format("%3s|", "é")`, "  é|"},
+			{`format("%q", "a")`, "\"a\""},
+			{`format("%v and %v", [1, 2], {"a": true})`, "[1, 2] and {a: true}"},
+			{`format("100%%")`, "100%"},
+			{`format("%d", 1.5)`, "format %d requires an integer, got 1.5"},
+			{`format("%d", 1e20)`, "format %d requires an integer of at most 64 bits, got 100000000000000000000"},
+			{`format("%x", -1e19)`, "format %x requires an integer of at most 64 bits, got -10000000000000000000"},
+			{`format("%d", 9223372036854775807)`, "format %d requires an integer of at most 64 bits, got 9223372036854776000"},
+			{`format("%d", -9223372036854775808)`, "-9223372036854775808"},
+			{`format("%d", 1 / 0)`, "format %d requires an integer, got inf"},
+			{`format("%1000000000d", 1)`, "format width and precision must be at most 268435456, got 1000000000"},
+			{`format("%.99999999999999999999f", 1)`, "format width and precision must be at most 268435456, got 99999999999999999999"},
+			{`format("%5-d", 1)`, "invalid format verb: %5-d"},
+			{`format("[%^7s]", "abc")`, "[  abc  ]"},
+			{`format("[%^6s]", "é")`, "[  é   ]"},
+			{`format("[%^8.2f]", 3.14159)`, "[  3.14  ]"},
+			{`format("[%^+6d]", 5)`, "[  +5  ]"},
+			{`format("[%^2s]", "abc")`, "[abc]"},
+			{`format("%f", "a")`, "format %f requires TypeNumber, got TypeString"},
+			{`format("%q", 1)`, "format %q requires TypeString, got TypeNumber"},
+			{`format("%d %d", 1)`, "not enough arguments for format"},
+			{`format("%d", 1, 2)`, "too many arguments for format: 1 unused"},
+			{`format("%k", 1)`, "unknown format verb: %k"},
+			{`format("50%")`, "format ends with an incomplete verb: %"},
+			{`format(1)`, "argument to `format` must be (TypeString), got TypeNumber"},
+			{`str(1000000) + "!"`, "1000000!"},
+			{`str([1, "a"])`, "[1, a]"},
+			{`num(" 2.5 ") * 2`, "5"},
+			{`num("1e3")`, "1000"},
+			{`num(true) + num(false)`, "1"},
+			{`num("abc")`, "could not parse \"abc\" as number"},
+			{`num([])`, "argument to `num` must be (TypeNumber, TypeBool, TypeString), got TypeArray"},
+			{`bool("true")`, "true"},
+			{`bool("false")`, "false"},
+			{`bool("yes")`, "could not parse \"yes\" as bool"},
+			{`bool(0)`, "false"},
+			{`bool([1])`, "true"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("math builtins", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`abs(-2)`, "2"},
+			{`floor(-1.5)`, "-2"},
+			{`ceil(1.2)`, "2"},
+			{`round(2.5)`, "3"},
+			{`trunc(-2.7)`, "-2"},
+			{`sqrt(16)`, "4"},
+			{`pow(2, 10)`, "1024"},
+			{`exp(0)`, "1"},
+			{`log(e)`, "1"},
+			{`log10(1000)`, "3"},
+			{`sin(0) + cos(0)`, "1"},
+			{`round(atan2(1, 1) * 4 * 1000) / 1000`, "3.142"},
+			{`pi`, "3.141592653589793"},
+			{`e`, "2.718281828459045"},
+			{`let e = 2; e`, "2"},
+			{`let f = fn(e) { e * 2 }; [f(3), e]`, "[6, 2.718281828459045]"},
+			{`inf`, "inf"},
+			{`-inf`, "-inf"},
+			{`nan`, "nan"},
+			{`isnan?(nan)`, "true"},
+			{`isnan?(sqrt(-1))`, "true"},
+			{`isinf?(1 / 0)`, "true"},
+			{`isinf?(1)`, "false"},
+			{`min([3, 1, 2])`, "1"},
+			{`1..5 | map(fn(x) { x * x }) | max`, "25"},
+			{`max([])`, "null"},
+			{`min([1, "a"])`, "elements of argument to `min` must be TypeNumber, got TypeString"},
+			{`clamp(0, 10, 15)`, "10"},
+			{`-5 | clamp(0, 10)`, "0"},
+			{`clamp(10, 0, 5)`, "clamp bounds out of order: 10 > 0"},
+			{`sqrt("a")`, "argument to `sqrt` must be (TypeNumber), got TypeString"},
+			{`[1, 4, 9] | map(sqrt)`, "[1, 2, 3]"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("random builtins", func(t *testing.T) {
+		evalSeeded := func(input string, seed int64) object.Object {
+			m, err := Compile(input)
+			if err != nil {
+				t.Fatalf("compilation should succeed; got err %v", err)
+			}
+			c := NewContext(object.NewRootScope())
+			c.Seed(seed)
+			return c.Eval(m)
+		}
+
+		draws := `[random(), random_int(1, 100), shuffle(1..10), sample(3, 1..10), choice(1..10)]`
+		first, again, other := evalSeeded(draws, 42), evalSeeded(draws, 42), evalSeeded(draws, 7)
+		if first.String() != again.String() {
+			t.Errorf("same seed should draw the same values; got %s and %s", first, again)
+		}
+		if first.String() == other.String() {
+			t.Errorf("different seeds should draw different values; got %s twice", first)
+		}
+
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`let xs = 1..1000 | map(fn(_) { random() }) | array; (min(xs) >= 0, max(xs) < 1)`, "(true, true)"},
+			{`1..1000 | map(fn(_) { random_int(-1, 1) }) | uniq | sort`, "[-1, 0, 1]"},
+			{`random_int(5, 5)`, "5"},
+			{`random_int(1.5, 2)`, "bounds of `random_int` must be integers, got 1.5 and 2"},
+			{`random_int(2, 1)`, "bounds of `random_int` out of order: 2 > 1"},
+			{`random_int(0, 1e300)`, "bounds of `random_int` must be within ±9007199254740992, got 0 and 1e+300"},
+			{`let n = random_int(-9007199254740992, 9007199254740992); n == trunc(n)`, "true"},
+			{`shuffle(1..10) | sort`, "[1, 2, 3, 4, 5, 6, 7, 8, 9, 10]"},
+			{`shuffle([])`, "[]"},
+			{`sample(3, 1..10) | uniq | len`, "3"},
+			{`sample(3, 1..10) | all?(fn(x) { member?(x, set(array(1..10))) })`, "true"},
+			{`sample(0, [1])`, "[]"},
+			{`sample(2, [1])`, "sample size out of range: 2 of 1"},
+			{`member?(choice(1..3), #{1, 2, 3})`, "true"},
+			{`choice([])`, "null"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := evalSeeded(tc.input, 1)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`json_stringify({"b": [1, 2.5, true], "a": "x"})`, `{"b":[1,2.5,true],"a":"x"}`},
+			{`json_stringify({"a": [1, {"b": []}], "c": {}}, 2)`, "{\n  \"a\": [\n    1,\n    {\n      \"b\": []\n    }\n  ],\n  \"c\": {}\n}"},
+			{`json_stringify([1, 2], "	")`, "[\n\t1,\n\t2\n]"},
+			{`json_stringify({1: (1, 2), true: #{3}})`, `{"1":[1,2],"true":[3]}`},
+			{`json_stringify([1000000, 0.1, -2])`, "[1000000,0.1,-2]"},
+			{`json_stringify("<é>")`, `"<é>"`},
+			{`json_stringify([if (false) { 1 }])`, "[null]"},
+			{`json_stringify(fn(x) { x })`, "cannot encode TypeFn as json"},
+			{`json_stringify([len])`, "cannot encode TypeBuiltin as json"},
+			{`json_stringify(1..3)`, "cannot encode TypeSeq as json"},
+			{`json_stringify(nan)`, "cannot encode nan as json"},
+			{`json_stringify({[1]: 2})`, "unusable as json key: TypeArray"},
+			{`json_stringify(1, [])`, "argument to `json_stringify` must be (TypeNumber, TypeString), got TypeArray"},
+			{`json_stringify()`, "wrong number of arguments. got=0, want=1 or 2"},
+			{`json_stringify([1], -1)`, "indent of `json_stringify` must be an integer from 0 to 10, got -1"},
+			{`json_stringify([1], 1.5)`, "indent of `json_stringify` must be an integer from 0 to 10, got 1.5"},
+			{`json_stringify([1], 1e18)`, "indent of `json_stringify` must be an integer from 0 to 10, got 1000000000000000000"},
+			{`json_stringify([1], 0)`, "[1]"},
+			{`json_parse("[1] 2")`, "invalid json: unexpected data after value"},
+			{`json_parse(json_stringify({"a": [1, {"b": "c"}]}))`, "{a: [1, {b: c}]}"},
+			{`let v = {"z": [1, 2.5, true, false], "a": {"b": "é"}}; json_parse(json_stringify(v, 2)) == v`, "true"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+
+		// String literals can't hold quotes
+		parse, stringify := jsonBuiltins["json_parse"].Impl, jsonBuiltins["json_stringify"].Impl
+		for input, output := range map[string]string{
+			`{"b": [1, 2.5e3, true, null, "x\"y"], "a": {}}`: `{b: [1, 2500, true, null, x"y], a: {}}`,
+			`"\u00e9"`:         "é",
+			`{"a": 1, "a": 2}`: "{a: 2}",
+			`[1, 2] [3]`:       "invalid json: unexpected data after value",
+		} {
+			if actual := parse(object.NewString(input)); actual.String() != output {
+				t.Errorf("json_parse(%q) should be %q; got %q", input, output, actual.String())
+			}
+		}
+
+		for _, input := range []string{`[1, 2`, `{"a": }`, `{1: 2}`, `nul`} {
+			actual := parse(object.NewString(input))
+			if !isError(actual) || !strings.HasPrefix(actual.String(), "invalid json: ") {
+				t.Errorf("json_parse(%q) should fail; got %q", input, actual.String())
+			}
+		}
+
+		for _, input := range []string{
+			`{"b":[1,2500,true,null,"x\"y\\z\n"],"a":{"é":{}}}`,
+			`[]`,
+			`[[[]],{}]`,
+			`-0.5`,
+		} {
+			value := parse(object.NewString(input))
+			if actual := stringify(value); actual.String() != input {
+				t.Errorf("json %q should round-trip; got %q", input, actual.String())
+			}
+		}
+
+		elms := make([]object.Object, 1)
+		cyclic := object.NewTuple(elms)
+		elms[0] = cyclic
+		if actual := stringify(cyclic); actual.String() != "cannot encode cyclic value as json" {
+			t.Errorf("cyclic values should not be encoded; got %q", actual.String())
+		}
+
+		inner := []object.Object{object.NewString("a"), nil}
+		nested := object.NewTuple(inner)
+		inner[1] = object.NewArray([]object.Object{nested})
+		if actual := stringify(object.NewHash().Assoc(object.NewString("t"), nested)); actual.String() != "cannot encode cyclic value as json" {
+			t.Errorf("nested cyclic values should not be encoded; got %q", actual.String())
+		}
+
+		shared := object.NewArray([]object.Object{object.NewNumber(1)})
+		if actual := stringify(object.NewArray([]object.Object{shared, shared})); actual.String() != "[[1],[1]]" {
+			t.Errorf("shared values should be encoded each time; got %q", actual.String())
+		}
+	})
+
+	t.Run("file system", func(t *testing.T) {
+		root := t.TempDir()
+		outside := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		links := map[string]string{
+			"link":     outside,
+			"dangling": filepath.Join(outside, "new.txt"),
+			"gone":     filepath.Join(outside, "new", "dir"),
+			"inner":    "c.txt",
+			"loop":     "loop",
+		}
+		for name, target := range links {
+			if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		evalWith := func(input string, caps Capabilities) object.Object {
+			m, err := Compile(input)
+			if err != nil {
+				t.Fatalf("compilation should succeed; got err %v", err)
+			}
+			scope := object.NewRootScope()
+			scope.Set("root", object.NewString(root))
+			scope.Set("outside", object.NewString(outside))
+			c := NewContext(scope)
+			c.Grant(caps)
+			return c.Eval(m)
+		}
+
+		full := Capabilities{Roots: []string{root}}
+		readOnly := Capabilities{Roots: []string{root}, ReadOnly: true}
+
+		tt := []struct {
+			input  string
+			caps   Capabilities
+			output string
+		}{
+			{`"hi" | write_file(root + "/a.txt"); read_file(root + "/a.txt")`, full, "hi"},
+			{`write_file(root + "/b.txt", "1"); append_file(root + "/b.txt", "2"); read_file(root + "/b.txt")`, full, "12"},
+			{`mkdir(root + "/d/e"); write_file(root + "/d/e/f.txt", ""); list_dir(root + "/d")`, full, "[e]"},
+			{`list_dir(root)`, full, "[a.txt, b.txt, d, dangling, gone, inner, link, loop]"},
+			{`exists?(root + "/a.txt")`, full, "true"},
+			{`exists?(root + "/z.txt")`, full, "false"},
+			{`remove(root + "/b.txt"); exists?(root + "/b.txt")`, full, "false"},
+			{`remove(root + "/d")`, full, "remove " + filepath.Join(root, "d") + ": directory not empty"},
+			{`read_file(root + "/d/../a.txt")`, readOnly, "hi"},
+			{`read_file(root + "/a.txt")`, Capabilities{}, "access denied: " + root + "/a.txt"},
+			{`read_file(outside + "/secret.txt")`, full, "access denied: " + outside + "/secret.txt"},
+			{`read_file(root + "/../secret.txt")`, full, "access denied: " + root + "/../secret.txt"},
+			{`read_file(root + "/link/secret.txt")`, full, "access denied: " + root + "/link/secret.txt"},
+			{`write_file(root + "/link/new.txt", "x")`, full, "access denied: " + root + "/link/new.txt"},
+			{`write_file(root + "/dangling", "x")`, full, "access denied: " + root + "/dangling"},
+			{`append_file(root + "/dangling", "x")`, full, "access denied: " + root + "/dangling"},
+			{`mkdir(root + "/gone")`, full, "access denied: " + root + "/gone"},
+			{`remove(root + "/dangling")`, full, "access denied: " + root + "/dangling"},
+			{`write_file(root + "/inner", "x"); read_file(root + "/c.txt")`, full, "x"},
+			{`read_file(root + "/loop")`, full, "too many links: " + root + "/loop"},
+			{`write_file(root + "/a.txt", "x")`, readOnly, "access denied (read-only): " + root + "/a.txt"},
+			{`mkdir(root + "/x")`, readOnly, "access denied (read-only): " + root + "/x"},
+			{`remove(root + "/a.txt")`, readOnly, "access denied (read-only): " + root + "/a.txt"},
+			{`read_file(root + "/a.txt")`, Capabilities{Roots: []string{outside, root}}, "hi"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := evalWith(tc.input, tc.caps)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+
+		for _, name := range []string{"new.txt", "new"} {
+			if _, err := os.Lstat(filepath.Join(outside, name)); err == nil {
+				t.Errorf("%s should not be created outside the roots", name)
+			}
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			stdin  string
+			output string
+		}{
+			{`read_line()`, "a\nb\n", "a"},
+			{`read_line(); read_line()`, "a\r\nb", "b"},
+			{`read_line(); read_line()`, "a\n", "null"},
+			{`read_line()`, "", "null"},
+			{`read_line(); read_all()`, "a\nb\nc\n", "b\nc\n"},
+			{`read_all()`, "", ""},
+			{`stdin_lines() | array`, "a\n\nb\n", "[a, , b]"},
+			{`stdin_lines() | map(num) | reduce(fn(a, b) { a + b }, 0)`, "1\n2\n3", "6"},
+			{`let first = stdin_lines() | take(1) | array; (first, read_all())`, "a\nb\n", "([a], b\n)"},
+			{`stdin_lines() | filter(fn(l) { l | starts_with?("#") }) | take(1) | array`, "a\n#b\n#c\n", "[#b]"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				m, err := Compile(tc.input)
+				if err != nil {
+					t.Fatalf("compilation should succeed; got err %v", err)
+				}
+				c := NewContext(object.NewRootScope())
+				c.SetStdin(strings.NewReader(tc.stdin))
+
+				actual := c.Eval(m)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
+	t.Run("output", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			stdout string
+			stderr string
+		}{
+			{`puts!("hello", 1, [2])`, "hello\n1\n[2]\n", ""},
+			{`eputs!("oops")`, "", "oops\n"},
+			{`puts!(); eputs!()`, "", ""},
+			{`puts!("a"); eputs!("b"); puts!("c")`, "a\nc\n", "b\n"},
+			{`[1, 2] | each(puts!)`, "1\n2\n", ""},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				m, err := Compile(tc.input)
+				if err != nil {
+					t.Fatalf("compilation should succeed; got err %v", err)
+				}
+				var stdout, stderr bytes.Buffer
+				c := NewContext(object.NewRootScope())
+				c.SetStdout(&stdout)
+				c.SetStderr(&stderr)
+
+				if actual := c.Eval(m); actual != Null {
+					t.Errorf("value should be null; got %q", actual.String())
+				}
+				if stdout.String() != tc.stdout {
+					t.Errorf("stdout should be %q; got %q", tc.stdout, stdout.String())
+				}
+				if stderr.String() != tc.stderr {
+					t.Errorf("stderr should be %q; got %q", tc.stderr, stderr.String())
+				}
+			})
+		}
+
+		m, err := Compile(`puts!(1 / 3, [0.5, {1: (2,)}]); eputs!(1); str(0.5)`)
+		if err != nil {
+			t.Fatalf("compilation should succeed; got err %v", err)
+		}
+		var stdout, stderr bytes.Buffer
+		c := NewContext(object.NewRootScope())
+		c.SetStdout(&stdout)
+		c.SetStderr(&stderr)
+		c.SetPrecision(2)
+
+		if actual := c.Eval(m); actual.String() != "0.5" {
+			t.Errorf("str should ignore the precision; got %q", actual.String())
+		}
+		if expected := "0.33\n[0.50, {1.00: (2.00,)}]\n"; stdout.String() != expected {
+			t.Errorf("stdout should be %q; got %q", expected, stdout.String())
+		}
+		if expected := "1.00\n"; stderr.String() != expected {
+			t.Errorf("stderr should be %q; got %q", expected, stderr.String())
+		}
+	})
+
+	t.Run("args and environment", func(t *testing.T) {
+		t.Setenv("GEO_TEST_VAR", "42")
+
+		tt := []struct {
+			input  string
+			args   []string
+			caps   Capabilities
+			output string
+		}{
+			{`args`, nil, Capabilities{}, "[]"},
+			{`args`, []string{"a", "-n", "1"}, Capabilities{}, "[a, -n, 1]"},
+			{`args | map(num) | reduce(fn(a, b) { a + b }, 0)`, []string{"1", "2"}, Capabilities{}, "3"},
+			{`let args = [1]; args`, []string{"a"}, Capabilities{}, "[1]"},
+			{`env("GEO_TEST_VAR")`, nil, Capabilities{Env: true}, "42"},
+			{`env("GEO_TEST_UNSET_VAR")`, nil, Capabilities{Env: true}, "null"},
+			{`env_all() | get("GEO_TEST_VAR")`, nil, Capabilities{Env: true}, "42"},
+			{`env("GEO_TEST_VAR")`, nil, Capabilities{}, "access denied: environment"},
+			{`env_all()`, nil, Capabilities{}, "access denied: environment"},
+			{`exit(3); puts!("unreachable")`, nil, Capabilities{}, "exit status 3"},
+			{`[1, 2] | each(fn(x) { exit(x) })`, nil, Capabilities{}, "exit status 1"},
+			{`exit(1.5)`, nil, Capabilities{}, "exit code must be an integer in [0, 255], got 1.5"},
+			{`exit(256)`, nil, Capabilities{}, "exit code must be an integer in [0, 255], got 256"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				m, err := Compile(tc.input)
+				if err != nil {
+					t.Fatalf("compilation should succeed; got err %v", err)
+				}
+				c := NewContext(object.NewRootScope())
+				c.SetArgs(tc.args)
+				c.Grant(tc.caps)
+
+				actual := c.Eval(m)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+
+		if code, ok := ExitCode(testEval(t, `exit(7)`)); !ok || code != 7 {
+			t.Errorf("exit code should be 7; got %d (%t)", code, ok)
+		}
+		if _, ok := ExitCode(testEval(t, `-"a"`)); ok {
+			t.Errorf("other errors should not be exits")
+		}
+	})
+
+	t.Run("error positions", func(t *testing.T) {
+		tt := []struct {
+			input string
+			line  int
+			col   int
+		}{
+			{"foo", 1, 1},
+			{`1 + "a"`, 1, 3},
+			{"let x = 1;\n  x(2)", 2, 4},
+			{"[1] | map(fn(x) {\n  -\"a\" })", 2, 3},
+			{`1..<"a"`, 1, 2},
+			{`len(1)`, 1, 4},
+			{`[1][0:"a"]`, 1, 4},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				err, ok := testEval(t, tc.input).(*object.Error)
+				if !ok {
+					t.Fatalf("evaluation should fail")
+				}
+				if err.Line != tc.line || err.Col != tc.col {
+					t.Errorf("error should be at %d:%d; got %d:%d", tc.line, tc.col, err.Line, err.Col)
+				}
+			})
+		}
+	})
+
+	t.Run("unicode strings", func(t *testing.T) {
+		tt := []struct {
+			input  string
+			output string
+		}{
+			{`len("héllo")`, "5"},
+			{`len("世界")`, "2"},
+			{`len("👍🏽")`, "2"},
+			{`"héllo"[1]`, "é"},
+			{`"世界"[1]`, "界"},
+			{`"世界"[2]`, "null"},
+			{`"世界"[-3]`, "null"},
+			{`slice(1, 3, "héllo")`, "él"},
+			{`slice(0, 10, "世界")`, "世界"},
+			{`slice(3, 1, "世界")`, ""},
+			{`slice(-5, -1, "世界")`, "世"},
+			{`slice(1, 3, [1, 2, 3, 4])`, "[2, 3]"},
+			{`bytes("é")`, "[195, 169]"},
+			{`bytes("a")`, "[97]"},
+			{`chars("héllo")`, "[h, é, l, l, o]"},
+			{"len(chars(\"e\u0301\"))", "1"},
+			{`len(chars("👍🏽👍"))`, "2"},
+			{`len(chars("🇧🇷🇵🇹"))`, "2"},
+			{`len(chars("👨‍👩‍👧"))`, "1"},
+			{`len(chars("한국어"))`, "3"},
+			{"len(chars(\"\u1112\u1161\u11ab\"))", "1"},
+			{"nfc(\"e\u0301\") == \"é\"", "true"},
+			{"len(nfc(\"e\u0301\"))", "1"},
+			{`len(nfd("é"))`, "2"},
+			{`fold("HeLLo") == fold("hello")`, "true"},
+			{`fold("Straße") == fold("STRASSE")`, "true"},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.input, func(t *testing.T) {
+				actual := testEval(t, tc.input)
+				if actual.String() != tc.output {
+					t.Errorf("value should be %q; got %q", tc.output, actual.String())
+				}
+			})
+		}
+	})
+
 	t.Run("map+reduce", func(t *testing.T) {
 		tt := []struct {
 			input string
@@ -496,7 +1458,7 @@ func TestEval(t *testing.T) {
 				if (len(arr) == 0) {
 					return acc
 				}
-				iter(push(acc, f(head(arr))), tail(arr))
+				iter(push(f(head(arr)), acc), tail(arr))
 			};
 
 			iter([], arr);
@@ -535,12 +1497,12 @@ func TestEval(t *testing.T) {
 				if !ok {
 					t.Errorf("value should be *object.Array; got %T", actual)
 				}
-				if len(ary.Elements) != len(val) {
-					t.Errorf("array should have %d elements; got %d", len(val), len(ary.Elements))
+				if ary.Len() != len(val) {
+					t.Errorf("array should have %d elements; got %d", len(val), ary.Len())
 				}
 				for i, it := range val {
 					t.Run(fmt.Sprintf("checking for value: %v", it), func(t *testing.T) {
-						testNumber(t, ary.Elements[i], float64(it))
+						testNumber(t, ary.At(i), float64(it))
 					})
 				}
 			case int:
@@ -579,6 +1541,89 @@ func TestEval(t *testing.T) {
 	})
 }
 
+func TestCheck(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x + pi | str", nil},
+		{"let even? = fn(n) { if (n == 0) { true } else { odd?(n - 1) } }; let odd? = fn(n) { !even?(n) }", nil},
+		{"[1, 2] | map(fn(x) { x * y })", []string{"at line 1, column 26: identifier not found: y"}},
+		{"if (true) { let x = 1 }; x", []string{"at line 1, column 26: identifier not found: x"}},
+		{`let f = fn(a) { a }; a; {"b": c}`, []string{
+			"at line 1, column 22: identifier not found: a",
+			"at line 1, column 31: identifier not found: c",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			m, err := Compile(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := NewContext(object.NewRootScope()).Check(m)
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d errors; got %v", len(tc.expected), errs)
+			}
+			for i, err := range errs {
+				if err.Error() != tc.expected[i] {
+					t.Errorf("expected %q; got %q", tc.expected[i], err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestBuiltinDocs(t *testing.T) {
+	for name, b := range NewContext(object.NewRootScope()).builtins {
+		if name == "import" {
+			continue // not done yet
+		}
+		if !strings.HasPrefix(b.Doc, b.Name+"(") {
+			t.Errorf("doc of %s should start with how it's called; got %q", name, b.Doc)
+		}
+	}
+}
+
+func BenchmarkReduce(b *testing.B) {
+	input := `
+	let reduce = fn(f, seed, arr) {
+		let iter = fn(acc, arr) {
+			if (len(arr) == 0) {
+				return acc
+			}
+			iter(f(acc, head(arr)), tail(arr))
+		};
+
+		iter(seed, arr);
+	};
+
+	xs | reduce(fn(acc, it) { acc + it }, 0);
+	`
+	m, err := Compile(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, n := range []int{100, 1000, 10000} {
+		elms := make([]object.Object, n)
+		for i := range elms {
+			elms[i] = object.NewNumber(float64(i))
+		}
+		xs := object.NewArray(elms)
+
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scope := object.NewRootScope()
+				scope.Set("xs", xs)
+				NewContext(scope).Eval(m)
+			}
+		})
+	}
+}
+
 func testEval(t *testing.T, input string) object.Object {
 	s, err := Compile(input)
 	if err != nil {
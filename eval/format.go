@@ -0,0 +1,220 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+var formatBuiltins = map[string]*object.Builtin{
+	// format takes printf-style verbs: %[flags][width][.precision]verb, where
+	// flags are - (align left), ^ (centre), + (always print the sign) and 0
+	// (pad with zeros), and verb is one of:
+	//
+	//	v  any value, as it prints
+	//	s  any value, as it prints; precision cuts it to as many characters
+	//	q  a string, quoted
+	//	d  an integer number, of at most 64 bits
+	//	x  an integer number of at most 64 bits, in hexadecimal
+	//	f  a number, with precision decimals (default 6)
+	//	e  a number, in scientific notation
+	//	%  a literal %
+	//
+	// Width and precision go up to maxStringLen. Centred values are padded
+	// with spaces, with the odd one on the right.
+	"format": &object.Builtin{
+		Name: "format",
+		Doc:  "format(template, args...) is template with each %verb replaced by the next of args, formatted.",
+		Impl: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments. got=0, want=1")
+			}
+			tmpl, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `format` must be (%s), got %s", object.TypeString, args[0].Type())
+			}
+			return format(tmpl.Value, args[1:])
+		},
+	},
+	"str": &object.Builtin{
+		Name:   "str",
+		Doc:    "str(x) is x as it prints.",
+		Params: []object.ObjectType{object.TypeAny},
+		Impl: func(args ...object.Object) object.Object {
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return object.NewString(args[0].String())
+		},
+	},
+	"num": &object.Builtin{
+		Name:   "num",
+		Doc:    "num(x) is the number a string holds, or 1 and 0 for true and false.",
+		Params: []object.ObjectType{object.TypeNumber | object.TypeString | object.TypeBool},
+		Impl: func(args ...object.Object) object.Object {
+			switch arg := args[0].(type) {
+			case *object.String:
+				v, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("could not parse %q as number", arg.Value)
+				}
+				return object.NewNumber(v)
+			case *object.Bool:
+				if arg.Value {
+					return object.NewNumber(1)
+				}
+				return object.NewNumber(0)
+			default:
+				return arg
+			}
+		},
+	},
+	// bool parses "true" and "false" from strings, and tells whether any
+	// other value is truthy.
+	"bool": &object.Builtin{
+		Name:   "bool",
+		Doc:    "bool(x) parses \"true\" and \"false\" from strings, or tells whether x is truthy.",
+		Params: []object.ObjectType{object.TypeAny},
+		Impl: func(args ...object.Object) object.Object {
+			if str, ok := args[0].(*object.String); ok {
+				switch strings.TrimSpace(str.Value) {
+				case "true":
+					return True
+				case "false":
+					return False
+				default:
+					return newError("could not parse %q as bool", str.Value)
+				}
+			}
+			if isTruthy(args[0]) {
+				return True
+			}
+
+			return False
+		},
+	},
+}
+
+func format(tmpl string, args []object.Object) object.Object {
+	var b strings.Builder
+	next := 0
+
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '%' {
+			b.WriteByte(tmpl[i])
+			continue
+		}
+
+		// Read the whole spec, then let fmt apply it
+		start := i
+		i++
+		for i < len(tmpl) && strings.IndexByte("-+0^123456789.", tmpl[i]) >= 0 {
+			i++
+		}
+		if i == len(tmpl) {
+			return newError("format ends with an incomplete verb: %s", tmpl[start:])
+		}
+		spec, verb := tmpl[start+1:i], tmpl[i]
+
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if next == len(args) {
+			return newError("not enough arguments for format")
+		}
+		arg := args[next]
+		next++
+
+		s, err := formatSpec(spec, verb, arg)
+		if err != nil {
+			return err
+		}
+		b.WriteString(s)
+	}
+
+	if next < len(args) {
+		return newError("too many arguments for format: %d unused", len(args)-next)
+	}
+	return object.NewString(b.String())
+}
+
+// formatSpec formats arg as spec, the flags, width and precision of the verb,
+// says. Width and precision are checked here, as fmt takes any and would
+// build strings as long as asked.
+func formatSpec(spec string, verb byte, arg object.Object) (string, object.Object) {
+	i := 0
+	for i < len(spec) && strings.IndexByte("-+0^", spec[i]) >= 0 {
+		i++
+	}
+	flags, width, prec := spec[:i], spec[i:], "" // prec keeps its dot
+	if j := strings.IndexByte(width, '.'); j >= 0 {
+		width, prec = width[:j], width[j:]
+	}
+	for _, n := range []string{width, strings.TrimPrefix(prec, ".")} {
+		if strings.Trim(n, "0123456789") != "" {
+			return "", newError("invalid format verb: %%%s%c", spec, verb)
+		}
+		if v, err := strconv.Atoi(n); n != "" && (err != nil || v > maxStringLen) {
+			return "", newError("format width and precision must be at most %d, got %s", maxStringLen, n)
+		}
+	}
+
+	if strings.IndexByte(flags, '^') < 0 {
+		return formatVerb("%"+spec, verb, arg)
+	}
+
+	// fmt has no centring: format without the width, then pad both sides
+	flags = strings.NewReplacer("^", "", "-", "", "0", "").Replace(flags)
+	s, err := formatVerb("%"+flags+prec, verb, arg)
+	if err != nil || width == "" {
+		return s, err
+	}
+	w, _ := strconv.Atoi(width)
+	pad := w - runeCount(s)
+	if pad <= 0 {
+		return s, nil
+	}
+	return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2), nil
+}
+
+func formatVerb(spec string, verb byte, arg object.Object) (string, object.Object) {
+	switch verb {
+	case 'v', 's':
+		return fmt.Sprintf(spec+"s", arg.String()), nil
+
+	case 'q':
+		str, ok := arg.(*object.String)
+		if !ok {
+			return "", newError("format %%%c requires %s, got %s", verb, object.TypeString, arg.Type())
+		}
+		return fmt.Sprintf(spec+"q", str.Value), nil
+
+	case 'd', 'x':
+		num, ok := arg.(*object.Number)
+		if !ok {
+			return "", newError("format %%%c requires %s, got %s", verb, object.TypeNumber, arg.Type())
+		}
+		if num.Value != math.Trunc(num.Value) || math.IsInf(num.Value, 0) {
+			return "", newError("format %%%c requires an integer, got %s", verb, num.String())
+		}
+		// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+		if num.Value < math.MinInt64 || num.Value >= math.MaxInt64 {
+			return "", newError("format %%%c requires an integer of at most 64 bits, got %s", verb, num.String())
+		}
+		return fmt.Sprintf(spec+string(verb), int64(num.Value)), nil
+
+	case 'f', 'e':
+		num, ok := arg.(*object.Number)
+		if !ok {
+			return "", newError("format %%%c requires %s, got %s", verb, object.TypeNumber, arg.Type())
+		}
+		return fmt.Sprintf(spec+string(verb), num.Value), nil
+
+	default:
+		return "", newError("unknown format verb: %%%c", verb)
+	}
+}
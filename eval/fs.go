@@ -0,0 +1,206 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+var errAccessDenied = errors.New("access denied")
+
+// checkPath resolves path and tells whether the capabilities allow to touch
+// it, for reading or also writing.
+func (c *Context) checkPath(path string, write bool) (string, object.Object) {
+	if write && c.caps.ReadOnly {
+		return "", newError("%s (read-only): %s", errAccessDenied, path)
+	}
+
+	real, err := realPath(path)
+	if err != nil {
+		return "", newError("%s", err)
+	}
+
+	for _, root := range c.caps.Roots {
+		root, err := realPath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, real)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return real, nil
+		}
+	}
+	return "", newError("%s: %s", errAccessDenied, path)
+}
+
+// maxLinks is how many symbolic links realPath follows, so loops end.
+const maxLinks = 255
+
+// realPath makes path absolute and resolves the symbolic links in it. Only the
+// part that exists can be resolved; the rest is kept as is. Links to missing
+// files are followed too, as writing through them creates their target.
+func realPath(path string) (string, error) {
+	return resolvePath(path, 0)
+}
+
+func resolvePath(path string, links int) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	missing := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, missing), nil
+		}
+		if target, err := os.Readlink(dir); err == nil {
+			if links == maxLinks {
+				return "", fmt.Errorf("too many links: %s", path)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(dir), target)
+			}
+			return resolvePath(filepath.Join(target, missing), links+1)
+		}
+		if dir == filepath.Dir(dir) {
+			return abs, nil
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+	}
+}
+
+// fsBuiltins access files through the context capabilities. The ones writing
+// take the content last, so it can be piped: `text | write_file("out.txt")`.
+func (c *Context) fsBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"read_file": &object.Builtin{
+			Name:   "read_file",
+			Doc:    "read_file(path) is the content of the file at path.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				path, err := c.checkPath(args[0].(*object.String).Value, false)
+				if err != nil {
+					return err
+				}
+				b, ioErr := ioutil.ReadFile(path)
+				if ioErr != nil {
+					return newError("%s", ioErr)
+				}
+				return object.NewString(string(b))
+			},
+		},
+		"write_file": &object.Builtin{
+			Name:   "write_file",
+			Doc:    "write_file(path, content) writes content to the file at path, replacing it.",
+			Params: []object.ObjectType{object.TypeString, object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				return c.writeFile(args[0].(*object.String).Value, args[1].(*object.String).Value, os.O_TRUNC)
+			},
+		},
+		"append_file": &object.Builtin{
+			Name:   "append_file",
+			Doc:    "append_file(path, content) writes content at the end of the file at path.",
+			Params: []object.ObjectType{object.TypeString, object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				return c.writeFile(args[0].(*object.String).Value, args[1].(*object.String).Value, os.O_APPEND)
+			},
+		},
+		// list_dir returns the names in the directory, sorted
+		"list_dir": &object.Builtin{
+			Name:   "list_dir",
+			Doc:    "list_dir(path) are the names in the directory at path, sorted.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				path, err := c.checkPath(args[0].(*object.String).Value, false)
+				if err != nil {
+					return err
+				}
+				infos, ioErr := ioutil.ReadDir(path)
+				if ioErr != nil {
+					return newError("%s", ioErr)
+				}
+				names := make([]string, len(infos))
+				for i, info := range infos {
+					names[i] = info.Name()
+				}
+				sort.Strings(names)
+				return stringArray(names)
+			},
+		},
+		"exists?": &object.Builtin{
+			Name:   "exists?",
+			Doc:    "exists?(path) tells whether there is a file at path.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				path, err := c.checkPath(args[0].(*object.String).Value, false)
+				if err != nil {
+					return err
+				}
+				if _, ioErr := os.Stat(path); ioErr != nil {
+					return False
+				}
+
+				return True
+			},
+		},
+		// mkdir creates the missing parents too
+		"mkdir": &object.Builtin{
+			Name:   "mkdir",
+			Doc:    "mkdir(path) creates the directory at path, and its missing parents.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				path, err := c.checkPath(args[0].(*object.String).Value, true)
+				if err != nil {
+					return err
+				}
+				if ioErr := os.MkdirAll(path, 0755); ioErr != nil {
+					return newError("%s", ioErr)
+				}
+				return Null
+			},
+		},
+		// remove removes a file or an empty directory
+		"remove": &object.Builtin{
+			Name:   "remove",
+			Doc:    "remove(path) removes the file or empty directory at path.",
+			Params: []object.ObjectType{object.TypeString},
+			Impl: func(args ...object.Object) object.Object {
+				path, err := c.checkPath(args[0].(*object.String).Value, true)
+				if err != nil {
+					return err
+				}
+				if ioErr := os.Remove(path); ioErr != nil {
+					return newError("%s", ioErr)
+				}
+				return Null
+			},
+		},
+	}
+}
+
+func (c *Context) writeFile(path, content string, mode int) object.Object {
+	path, err := c.checkPath(path, true)
+	if err != nil {
+		return err
+	}
+
+	f, ioErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0644)
+	if ioErr != nil {
+		return newError("%s", ioErr)
+	}
+	_, ioErr = f.WriteString(content)
+	if closeErr := f.Close(); ioErr == nil {
+		ioErr = closeErr
+	}
+	if ioErr != nil {
+		return newError("%s", ioErr)
+	}
+	return Null
+}
@@ -0,0 +1,109 @@
+package eval
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// SetStdin sets where the stdin builtins read from, os.Stdin by default.
+func (c *Context) SetStdin(r io.Reader) {
+	c.stdin = bufio.NewReader(r)
+}
+
+// SetStdout sets where puts! writes to, os.Stdout by default.
+func (c *Context) SetStdout(w io.Writer) {
+	c.stdout = w
+}
+
+// SetStderr sets where eputs! writes to, os.Stderr by default.
+func (c *Context) SetStderr(w io.Writer) {
+	c.stderr = w
+}
+
+// puts writes each value on a line of its own.
+func (c *Context) puts(w io.Writer, values []object.Object) object.Object {
+	for _, v := range values {
+		if _, err := fmt.Fprintln(w, c.Format(v)); err != nil {
+			return newError("%s", err)
+		}
+	}
+
+	return Null
+}
+
+// readLine reads the next line from stdin, without its line break. ok is false
+// at the end of the input.
+func (c *Context) readLine() (line object.Object, ok bool) {
+	s, err := c.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return newError("%s", err), true
+	}
+	if err == io.EOF && s == "" {
+		return nil, false
+	}
+
+	s = strings.TrimSuffix(s, "\n")
+	s = strings.TrimSuffix(s, "\r")
+	return object.NewString(s), true
+}
+
+func (c *Context) ioBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"puts!": &object.Builtin{
+			Name: "puts!",
+			Doc:  "puts!(xs...) writes each value to stdout, on a line of its own.",
+			Impl: func(args ...object.Object) object.Object {
+				return c.puts(c.stdout, args)
+			},
+		},
+		"eputs!": &object.Builtin{
+			Name: "eputs!",
+			Doc:  "eputs!(xs...) writes each value to stderr, on a line of its own.",
+			Impl: func(args ...object.Object) object.Object {
+				return c.puts(c.stderr, args)
+			},
+		},
+		// read_line returns null at the end of the input
+		"read_line": &object.Builtin{
+			Name:   "read_line",
+			Doc:    "read_line() is the next line of stdin, or null at its end.",
+			Params: []object.ObjectType{},
+			Impl: func(args ...object.Object) object.Object {
+				if line, ok := c.readLine(); ok {
+					return line
+				}
+				return Null
+			},
+		},
+		"read_all": &object.Builtin{
+			Name:   "read_all",
+			Doc:    "read_all() is the rest of stdin.",
+			Params: []object.ObjectType{},
+			Impl: func(args ...object.Object) object.Object {
+				b, err := ioutil.ReadAll(c.stdin)
+				if err != nil {
+					return newError("%s", err)
+				}
+				return object.NewString(string(b))
+			},
+		},
+		// stdin_lines reads lines only as they are needed. Input can't be
+		// read twice, so iterating the seq again continues where the last
+		// iteration stopped.
+		"stdin_lines": &object.Builtin{
+			Name:   "stdin_lines",
+			Doc:    "stdin_lines() are the lines of stdin, read as they are needed.",
+			Params: []object.ObjectType{},
+			Impl: func(args ...object.Object) object.Object {
+				return object.NewSeq(func() object.Iterator {
+					return object.IteratorFunc(c.readLine)
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,243 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// maxJSONIndent is the most spaces json_stringify indents by, as in JavaScript
+const maxJSONIndent = 10
+
+var jsonBuiltins = map[string]*object.Builtin{
+	"json_parse": &object.Builtin{
+		Name:   "json_parse",
+		Doc:    "json_parse(s) is the value the JSON text s holds.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			dec := json.NewDecoder(strings.NewReader(args[0].(*object.String).Value))
+
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return newError("invalid json: %s", err)
+			}
+			if _, err := dec.Token(); err != io.EOF {
+				return newError("invalid json: unexpected data after value")
+			}
+			return value
+		},
+	},
+	// json_stringify(value, indent?) prints hashes in their insertion order.
+	// With an indent (a number of spaces, up to maxJSONIndent, or a string), it
+	// prints one element per line.
+	"json_stringify": &object.Builtin{
+		Name: "json_stringify",
+		Doc:  "json_stringify(x, indent?) is x as JSON text, with one element per line when indented.",
+		Impl: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			e := &jsonEncoder{seen: map[object.Object]bool{}}
+			if len(args) == 2 {
+				switch indent := args[1].(type) {
+				case *object.Number:
+					n := indent.Value
+					if n < 0 || n > maxJSONIndent || n != math.Trunc(n) {
+						return newError("indent of `json_stringify` must be an integer from 0 to %d, got %s", maxJSONIndent, indent.String())
+					}
+					e.indent = strings.Repeat(" ", int(n))
+				case *object.String:
+					e.indent = indent.Value
+				default:
+					return newError("argument to `json_stringify` must be (%s), got %s", object.ObjectTypesToString(object.TypeNumber|object.TypeString), args[1].Type())
+				}
+			}
+
+			if err := e.encode(args[0], 0); err != nil {
+				return err
+			}
+			return object.NewString(e.buf.String())
+		},
+	},
+}
+
+func decodeJSON(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			elms := []object.Object{}
+			for dec.More() {
+				elm, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elms = append(elms, elm)
+			}
+			_, err := dec.Token() // ]
+			return object.NewArray(elms), err
+		}
+
+		hash := object.NewHash()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			hash = hash.Assoc(object.NewString(key.(string)), value)
+		}
+		_, err := dec.Token() // }
+		return hash, err
+
+	case string:
+		return object.NewString(tok), nil
+	case float64:
+		return object.NewNumber(tok), nil
+	case bool:
+		if tok {
+			return True, nil
+		}
+		return False, nil
+	default:
+		return Null, nil
+	}
+}
+
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	indent string
+	seen   map[object.Object]bool // composites being encoded, to catch cycles
+}
+
+func (e *jsonEncoder) encode(value object.Object, depth int) object.Object {
+	switch value := value.(type) {
+	case *object.Null:
+		e.buf.WriteString("null")
+
+	case *object.Bool:
+		e.buf.WriteString(value.String())
+
+	case *object.Number:
+		if math.IsNaN(value.Value) || math.IsInf(value.Value, 0) {
+			return newError("cannot encode %s as json", value.String())
+		}
+		e.buf.WriteString(object.FormatNumber(value.Value, -1))
+
+	case *object.String:
+		e.writeString(value.Value)
+
+	case *object.Array:
+		return e.encodeList(value, value.Elements(), depth)
+
+	case *object.Tuple:
+		return e.encodeList(value, value.Elements(), depth)
+
+	case *object.Set:
+		return e.encodeList(value, value.Elements(), depth)
+
+	case *object.Hash:
+		if e.seen[value] {
+			return newError("cannot encode cyclic value as json")
+		}
+		e.seen[value] = true
+		defer delete(e.seen, value)
+
+		pairs := value.Pairs()
+		if len(pairs) == 0 {
+			e.buf.WriteString("{}")
+			return nil
+		}
+
+		e.buf.WriteByte('{')
+		for i, p := range pairs {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.newline(depth + 1)
+
+			switch key := p.Key.(type) {
+			case *object.String:
+				e.writeString(key.Value)
+			case *object.Number, *object.Bool:
+				e.writeString(key.String())
+			default:
+				return newError("unusable as json key: %s", p.Key.Type())
+			}
+			e.buf.WriteByte(':')
+			if e.indent != "" {
+				e.buf.WriteByte(' ')
+			}
+
+			if err := e.encode(p.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.buf.WriteByte('}')
+
+	default:
+		return newError("cannot encode %s as json", value.Type())
+	}
+
+	return nil
+}
+
+// encodeList encodes the elements of list. Scripts can't build cycles, but
+// hosts can, as by sharing the slice given to object.NewTuple, so list is
+// tracked like hashes are.
+func (e *jsonEncoder) encodeList(list object.Object, elms []object.Object, depth int) object.Object {
+	if e.seen[list] {
+		return newError("cannot encode cyclic value as json")
+	}
+	e.seen[list] = true
+	defer delete(e.seen, list)
+
+	if len(elms) == 0 {
+		e.buf.WriteString("[]")
+		return nil
+	}
+
+	e.buf.WriteByte('[')
+	for i, elm := range elms {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.newline(depth + 1)
+		if err := e.encode(elm, depth+1); err != nil {
+			return err
+		}
+	}
+	e.newline(depth)
+	e.buf.WriteByte(']')
+
+	return nil
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+	e.buf.WriteByte('\n')
+	e.buf.WriteString(strings.Repeat(e.indent, depth))
+}
+
+func (e *jsonEncoder) writeString(s string) {
+	// Marshal would escape <, > and &, which is only needed for HTML
+	enc := json.NewEncoder(&e.buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	e.buf.Truncate(e.buf.Len() - 1) // Encode ends with a line break
+}
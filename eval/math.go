@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"math"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// constants are looked up after scopes and builtins, so scripts can still
+// shadow them, like `let e = ...`, and a function may take an e parameter.
+var constants = map[string]object.Object{
+	"pi":  object.NewNumber(math.Pi),
+	"e":   object.NewNumber(math.E),
+	"inf": object.NewNumber(math.Inf(1)),
+	"nan": object.NewNumber(math.NaN()),
+}
+
+var mathBuiltins = map[string]*object.Builtin{
+	"abs":   mathFn("abs", "abs(x) is the absolute value of x.", math.Abs),
+	"floor": mathFn("floor", "floor(x) is the greatest integer not above x.", math.Floor),
+	"ceil":  mathFn("ceil", "ceil(x) is the least integer not below x.", math.Ceil),
+	"round": mathFn("round", "round(x) is the integer nearest to x, rounding halves away from zero.", math.Round),
+	"trunc": mathFn("trunc", "trunc(x) is x without its fractional part.", math.Trunc),
+	"sqrt":  mathFn("sqrt", "sqrt(x) is the square root of x.", math.Sqrt),
+	"exp":   mathFn("exp", "exp(x) is e to the power of x.", math.Exp),
+	"log":   mathFn("log", "log(x) is the natural logarithm of x.", math.Log),
+	"log2":  mathFn("log2", "log2(x) is the binary logarithm of x.", math.Log2),
+	"log10": mathFn("log10", "log10(x) is the decimal logarithm of x.", math.Log10),
+	"sin":   mathFn("sin", "sin(x) is the sine of x radians.", math.Sin),
+	"cos":   mathFn("cos", "cos(x) is the cosine of x radians.", math.Cos),
+	"tan":   mathFn("tan", "tan(x) is the tangent of x radians.", math.Tan),
+	"asin":  mathFn("asin", "asin(x) is the arc sine of x, in radians.", math.Asin),
+	"acos":  mathFn("acos", "acos(x) is the arc cosine of x, in radians.", math.Acos),
+	"atan":  mathFn("atan", "atan(x) is the arc tangent of x, in radians.", math.Atan),
+	// pow and atan2 keep the usual order of their arguments, unlike the
+	// collection builtins.
+	"pow": &object.Builtin{
+		Name:   "pow",
+		Doc:    "pow(x, y) is x to the power of y.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewNumber(math.Pow(args[0].(*object.Number).Value, args[1].(*object.Number).Value))
+		},
+	},
+	"atan2": &object.Builtin{
+		Name:   "atan2",
+		Doc:    "atan2(y, x) is the arc tangent of y/x, using their signs to tell the quadrant.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewNumber(math.Atan2(args[0].(*object.Number).Value, args[1].(*object.Number).Value))
+		},
+	},
+	"min": &object.Builtin{
+		Name:   "min",
+		Doc:    "min(xs) is the smallest number of xs, or null when it is empty.",
+		Params: []object.ObjectType{iterableTypes},
+		Impl: func(args ...object.Object) object.Object {
+			return extreme("min", args[0], func(a, b float64) bool { return a < b })
+		},
+	},
+	"max": &object.Builtin{
+		Name:   "max",
+		Doc:    "max(xs) is the largest number of xs, or null when it is empty.",
+		Params: []object.ObjectType{iterableTypes},
+		Impl: func(args ...object.Object) object.Object {
+			return extreme("max", args[0], func(a, b float64) bool { return a > b })
+		},
+	},
+	"clamp": &object.Builtin{
+		Name:   "clamp",
+		Doc:    "clamp(lo, hi, x) is x kept within lo and hi.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeNumber, object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			lo := args[0].(*object.Number).Value
+			hi := args[1].(*object.Number).Value
+			if lo > hi {
+				return newError("clamp bounds out of order: %s > %s", args[0].String(), args[1].String())
+			}
+			return object.NewNumber(math.Max(lo, math.Min(hi, args[2].(*object.Number).Value)))
+		},
+	},
+	"isnan?": &object.Builtin{
+		Name:   "isnan?",
+		Doc:    "isnan?(x) tells whether x is nan.",
+		Params: []object.ObjectType{object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			if math.IsNaN(args[0].(*object.Number).Value) {
+				return True
+			}
+
+			return False
+		},
+	},
+	"isinf?": &object.Builtin{
+		Name:   "isinf?",
+		Doc:    "isinf?(x) tells whether x is infinite.",
+		Params: []object.ObjectType{object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			if math.IsInf(args[0].(*object.Number).Value, 0) {
+				return True
+			}
+
+			return False
+		},
+	},
+}
+
+func mathFn(name, doc string, f func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Name:   name,
+		Doc:    doc,
+		Params: []object.ObjectType{object.TypeNumber},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewNumber(f(args[0].(*object.Number).Value))
+		},
+	}
+}
+
+// extreme returns the number in coll that wins over all others, or null when
+// coll is empty.
+func extreme(name string, coll object.Object, wins func(a, b float64) bool) object.Object {
+	var best *object.Number
+
+	it := iterOf(coll)
+	for {
+		elm, ok := it.Next()
+		if !ok {
+			break
+		}
+		if isError(elm) {
+			return elm
+		}
+		num, ok := elm.(*object.Number)
+		if !ok {
+			return newError("elements of argument to `%s` must be %s, got %s", name, object.TypeNumber, elm.Type())
+		}
+		if best == nil || wins(num.Value, best.Value) {
+			best = num
+		}
+	}
+
+	if best == nil {
+		return Null
+	}
+	return best
+}
@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"math"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// maxExactInt is the largest integer numbers hold exactly; all smaller ones are
+// held exactly too.
+const maxExactInt = 1 << 53
+
+// randomBuiltins draw from the context PRNG, so seeding the context (see
+// Context.Seed) makes them repeat the same values.
+func (c *Context) randomBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		// random returns a number in [0, 1)
+		"random": &object.Builtin{
+			Name:   "random",
+			Doc:    "random() is a random number in [0, 1).",
+			Params: []object.ObjectType{},
+			Impl: func(args ...object.Object) object.Object {
+				return object.NewNumber(c.rand.Float64())
+			},
+		},
+		// random_int returns an integer in [lo, hi], both included. Bounds are
+		// kept within ±maxExactInt, so the span always fits in an int64.
+		"random_int": &object.Builtin{
+			Name:   "random_int",
+			Doc:    "random_int(lo, hi) is a random integer in [lo, hi].",
+			Params: []object.ObjectType{object.TypeNumber, object.TypeNumber},
+			Impl: func(args ...object.Object) object.Object {
+				lo, hi := args[0].(*object.Number).Value, args[1].(*object.Number).Value
+				if lo != math.Trunc(lo) || hi != math.Trunc(hi) {
+					return newError("bounds of `random_int` must be integers, got %s and %s", args[0].String(), args[1].String())
+				}
+				if math.Abs(lo) > maxExactInt || math.Abs(hi) > maxExactInt {
+					return newError("bounds of `random_int` must be within ±%d, got %s and %s", int64(maxExactInt), args[0].String(), args[1].String())
+				}
+				if lo > hi {
+					return newError("bounds of `random_int` out of order: %s > %s", args[0].String(), args[1].String())
+				}
+				return object.NewNumber(lo + float64(c.rand.Int63n(int64(hi-lo)+1)))
+			},
+		},
+		"shuffle": &object.Builtin{
+			Name:   "shuffle",
+			Doc:    "shuffle(xs) is an array of the elements of xs, in random order.",
+			Params: []object.ObjectType{iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				result := collect(iterOf(args[0]))
+				if isError(result) {
+					return result
+				}
+				elms := result.(*object.Array).Elements()
+				c.rand.Shuffle(len(elms), func(i, j int) {
+					elms[i], elms[j] = elms[j], elms[i]
+				})
+				return object.NewArray(elms)
+			},
+		},
+		// sample picks n distinct elements (by position) in random order
+		"sample": &object.Builtin{
+			Name:   "sample",
+			Doc:    "sample(n, xs) is an array of n elements of xs, picked at random.",
+			Params: []object.ObjectType{object.TypeNumber, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				result := collect(iterOf(args[1]))
+				if isError(result) {
+					return result
+				}
+				elms := result.(*object.Array).Elements()
+
+				n := int(args[0].(*object.Number).Value)
+				if n < 0 || n > len(elms) {
+					return newError("sample size out of range: %d of %d", n, len(elms))
+				}
+				// Partial Fisher-Yates, only the first n positions are needed
+				for i := 0; i < n; i++ {
+					j := i + c.rand.Intn(len(elms)-i)
+					elms[i], elms[j] = elms[j], elms[i]
+				}
+				return object.NewArray(elms[:n])
+			},
+		},
+		"choice": &object.Builtin{
+			Name:   "choice",
+			Doc:    "choice(xs) is an element of xs picked at random, or null when it is empty.",
+			Params: []object.ObjectType{iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				result := collect(iterOf(args[0]))
+				if isError(result) {
+					return result
+				}
+				ary := result.(*object.Array)
+				if ary.Len() == 0 {
+					return Null
+				}
+				return ary.At(c.rand.Intn(ary.Len()))
+			},
+		},
+	}
+}
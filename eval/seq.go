@@ -0,0 +1,206 @@
+package eval
+
+import "github.com/geovanisouza92/geo/object"
+
+// iterableTypes are the types seq builtins accept as collections.
+const iterableTypes = object.TypeArray | object.TypeTuple | object.TypeSet | object.TypeSeq
+
+const callableTypes = object.TypeFn | object.TypeBuiltin
+
+// seqBuiltins are the builtins consuming and producing sequences. Given a seq
+// they return a lazy seq, given anything else they return an array. Errors
+// raised while iterating are yielded as elements, and stop whoever collects
+// them.
+func (c *Context) seqBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"map": &object.Builtin{
+			Name:   "map",
+			Doc:    "map(f, xs) are the results of f(x) for each x.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				f, coll := args[0], args[1]
+				return lazy(coll, func() object.Iterator {
+					it := iterOf(coll)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						elm, ok := it.Next()
+						if !ok || isError(elm) {
+							return elm, ok
+						}
+						return c.applyFn(f, elm), true
+					})
+				})
+			},
+		},
+		"filter": &object.Builtin{
+			Name:   "filter",
+			Doc:    "filter(f, xs) are the elements x for which f(x) is truthy.",
+			Params: []object.ObjectType{callableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				f, coll := args[0], args[1]
+				return lazy(coll, func() object.Iterator {
+					it := iterOf(coll)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						for {
+							elm, ok := it.Next()
+							if !ok || isError(elm) {
+								return elm, ok
+							}
+							keep := c.applyFn(f, elm)
+							if isError(keep) {
+								return keep, true
+							}
+							if isTruthy(keep) {
+								return elm, true
+							}
+						}
+					})
+				})
+			},
+		},
+		"take": &object.Builtin{
+			Name:   "take",
+			Doc:    "take(n, xs) are the first n elements of xs.",
+			Params: []object.ObjectType{object.TypeNumber, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				n, ok := toInt(args[0].(*object.Number).Value)
+				if !ok {
+					return newError("argument to `take` must not be nan")
+				}
+				coll := args[1]
+				return lazy(coll, func() object.Iterator {
+					it := iterOf(coll)
+					taken := 0
+					return object.IteratorFunc(func() (object.Object, bool) {
+						if taken >= n {
+							return nil, false
+						}
+						taken++
+						return it.Next()
+					})
+				})
+			},
+		},
+		"drop": &object.Builtin{
+			Name:   "drop",
+			Doc:    "drop(n, xs) are the elements of xs after the first n.",
+			Params: []object.ObjectType{object.TypeNumber, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				n, ok := toInt(args[0].(*object.Number).Value)
+				if !ok {
+					return newError("argument to `drop` must not be nan")
+				}
+				coll := args[1]
+				return lazy(coll, func() object.Iterator {
+					it := iterOf(coll)
+					skip := n
+					return object.IteratorFunc(func() (object.Object, bool) {
+						for ; skip > 0; skip-- {
+							if _, ok := it.Next(); !ok {
+								break
+							}
+						}
+						return it.Next()
+					})
+				})
+			},
+		},
+		// zip pairs the elements of both collections in tuples, stopping at
+		// the shortest one. Piping puts the left side second: `a | zip(b)`
+		// gives tuples of (b, a).
+		"zip": &object.Builtin{
+			Name:   "zip",
+			Doc:    "zip(b, a) are the tuples (b, a) of the elements of both, up to the shortest.",
+			Params: []object.ObjectType{iterableTypes, iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				a, b := args[0], args[1]
+				zipped := func() object.Iterator {
+					ita, itb := iterOf(a), iterOf(b)
+					return object.IteratorFunc(func() (object.Object, bool) {
+						x, ok := ita.Next()
+						if !ok || isError(x) {
+							return x, ok
+						}
+						y, ok := itb.Next()
+						if !ok || isError(y) {
+							return y, ok
+						}
+						return object.NewTuple([]object.Object{x, y}), true
+					})
+				}
+				if _, ok := a.(*object.Seq); ok {
+					return object.NewSeq(zipped)
+				}
+				return lazy(b, zipped)
+			},
+		},
+		"array": &object.Builtin{
+			Name:   "array",
+			Doc:    "array(xs) is an array of the elements of xs, running a seq.",
+			Params: []object.ObjectType{iterableTypes},
+			Impl: func(args ...object.Object) object.Object {
+				if ary, ok := args[0].(*object.Array); ok {
+					return ary
+				}
+				return collect(iterOf(args[0]))
+			},
+		},
+	}
+}
+
+// lazy returns a seq over iter when coll is a seq, or collects it into an
+// array otherwise.
+func lazy(coll object.Object, iter func() object.Iterator) object.Object {
+	if _, ok := coll.(*object.Seq); ok {
+		return object.NewSeq(iter)
+	}
+	return collect(iter())
+}
+
+// collect reads it into an array, or returns the first error yielded.
+func collect(it object.Iterator) object.Object {
+	elms := []object.Object{}
+	for {
+		elm, ok := it.Next()
+		if !ok {
+			return object.NewArray(elms)
+		}
+		if isError(elm) {
+			return elm
+		}
+		elms = append(elms, elm)
+	}
+}
+
+// iterOf returns an iterator over any of the iterableTypes.
+func iterOf(coll object.Object) object.Iterator {
+	switch coll := coll.(type) {
+	case *object.Seq:
+		return coll.Iter()
+	case *object.Array:
+		i := 0
+		return object.IteratorFunc(func() (object.Object, bool) {
+			if i >= coll.Len() {
+				return nil, false
+			}
+			i++
+			return coll.At(i - 1), true
+		})
+	case *object.Tuple:
+		return elementsIter(coll.Elements())
+	case *object.Set:
+		return elementsIter(coll.Elements())
+	default:
+		return elementsIter(nil)
+	}
+}
+
+func elementsIter(elms []object.Object) object.Iterator {
+	i := 0
+	return object.IteratorFunc(func() (object.Object, bool) {
+		if i >= len(elms) {
+			return nil, false
+		}
+		i++
+		return elms[i-1], true
+	})
+}
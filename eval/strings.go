@@ -0,0 +1,246 @@
+package eval
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
+	"github.com/geovanisouza92/geo/object"
+)
+
+// maxStringLen is the longest string, in bytes, that repeating and padding
+// may build, so a typo can't take all the memory.
+const maxStringLen = 1 << 28
+
+// stringBuiltins take the string being worked on last, so they can be piped:
+// `name | trim | lower | split(" ")`. Indexes and widths count runes, like
+// string indexing does.
+var stringBuiltins = map[string]*object.Builtin{
+	"split": &object.Builtin{
+		Name:   "split",
+		Doc:    "split(sep, s) are the parts of s between each sep; an empty sep splits s into characters.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			sep, str := args[0].(*object.String).Value, args[1].(*object.String).Value
+
+			var parts []string
+			if sep == "" {
+				parts = graphemes(str)
+			} else {
+				parts = strings.Split(str, sep)
+			}
+			return stringArray(parts)
+		},
+	},
+	"join": &object.Builtin{
+		Name:   "join",
+		Doc:    "join(sep, xs) is a string of the elements of xs, with sep between them.",
+		Params: []object.ObjectType{object.TypeString, iterableTypes},
+		Impl: func(args ...object.Object) object.Object {
+			sep := args[0].(*object.String).Value
+
+			parts := []string{}
+			it := iterOf(args[1])
+			for {
+				elm, ok := it.Next()
+				if !ok {
+					return object.NewString(strings.Join(parts, sep))
+				}
+				if isError(elm) {
+					return elm
+				}
+				parts = append(parts, elm.String())
+			}
+		},
+	},
+	"trim": &object.Builtin{
+		Name:   "trim",
+		Doc:    "trim(s) is s without leading and trailing white space.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(strings.TrimSpace(args[0].(*object.String).Value))
+		},
+	},
+	"upper": &object.Builtin{
+		Name:   "upper",
+		Doc:    "upper(s) is s in upper case.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(cases.Upper(language.Und).String(args[0].(*object.String).Value))
+		},
+	},
+	"lower": &object.Builtin{
+		Name:   "lower",
+		Doc:    "lower(s) is s in lower case.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return object.NewString(cases.Lower(language.Und).String(args[0].(*object.String).Value))
+		},
+	},
+	"replace": &object.Builtin{
+		Name:   "replace",
+		Doc:    "replace(old, new, s) is s with every old replaced by new.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			old, new := args[0].(*object.String).Value, args[1].(*object.String).Value
+			return object.NewString(strings.ReplaceAll(args[2].(*object.String).Value, old, new))
+		},
+	},
+	"contains?": &object.Builtin{
+		Name:   "contains?",
+		Doc:    "contains?(sub, s) tells whether sub is in s.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			if strings.Contains(args[1].(*object.String).Value, args[0].(*object.String).Value) {
+				return True
+			}
+
+			return False
+		},
+	},
+	"starts_with?": &object.Builtin{
+		Name:   "starts_with?",
+		Doc:    "starts_with?(prefix, s) tells whether s starts with prefix.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			if strings.HasPrefix(args[1].(*object.String).Value, args[0].(*object.String).Value) {
+				return True
+			}
+
+			return False
+		},
+	},
+	"ends_with?": &object.Builtin{
+		Name:   "ends_with?",
+		Doc:    "ends_with?(suffix, s) tells whether s ends with suffix.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			if strings.HasSuffix(args[1].(*object.String).Value, args[0].(*object.String).Value) {
+				return True
+			}
+
+			return False
+		},
+	},
+	"index_of": &object.Builtin{
+		Name:   "index_of",
+		Doc:    "index_of(sub, s) is the index of the first sub in s, or -1.",
+		Params: []object.ObjectType{object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			str := args[1].(*object.String).Value
+			i := strings.Index(str, args[0].(*object.String).Value)
+			if i >= 0 {
+				i = runeCount(str[:i])
+			}
+			return object.NewNumber(float64(i))
+		},
+	},
+	"repeat": &object.Builtin{
+		Name:   "repeat",
+		Doc:    "repeat(n, s) is s repeated n times.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			return repeatString(args[1].(*object.String).Value, args[0].(*object.Number).Value)
+		},
+	},
+	"pad_left": &object.Builtin{
+		Name:   "pad_left",
+		Doc:    "pad_left(width, pad, s) is s preceded by as much pad as makes it width characters long.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			str := args[2].(*object.String).Value
+			fill, err := padding(args[0].(*object.Number).Value, args[1].(*object.String).Value, str)
+			if err != nil {
+				return err
+			}
+			return object.NewString(fill + str)
+		},
+	},
+	"pad_right": &object.Builtin{
+		Name:   "pad_right",
+		Doc:    "pad_right(width, pad, s) is s followed by as much pad as makes it width characters long.",
+		Params: []object.ObjectType{object.TypeNumber, object.TypeString, object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			str := args[2].(*object.String).Value
+			fill, err := padding(args[0].(*object.Number).Value, args[1].(*object.String).Value, str)
+			if err != nil {
+				return err
+			}
+			return object.NewString(str + fill)
+		},
+	},
+	// lines splits on \n or \r\n; a trailing line break does not start an
+	// empty line.
+	"lines": &object.Builtin{
+		Name:   "lines",
+		Doc:    "lines(s) are the lines of s, without their line breaks.",
+		Params: []object.ObjectType{object.TypeString},
+		Impl: func(args ...object.Object) object.Object {
+			str := strings.TrimSuffix(args[0].(*object.String).Value, "\n")
+			if str == "" {
+				return stringArray(nil)
+			}
+
+			parts := strings.Split(str, "\n")
+			for i, p := range parts {
+				parts[i] = strings.TrimSuffix(p, "\r")
+			}
+			return stringArray(parts)
+		},
+	},
+}
+
+func stringArray(parts []string) *object.Array {
+	elms := make([]object.Object, len(parts))
+	for i, p := range parts {
+		elms[i] = object.NewString(p)
+	}
+	return object.NewArray(elms)
+}
+
+func repeatString(str string, n float64) object.Object {
+	// Infinities are integers to Trunc, and NaN compares false, so they are
+	// told apart before anything else
+	if math.IsNaN(n) || math.IsInf(n, 0) || n < 0 || n != math.Trunc(n) {
+		return newError("repeat count must be a non-negative integer, got %s", object.FormatNumber(n, -1))
+	}
+	if n > maxStringLen {
+		return newError("repeat count must be at most %d, got %s", maxStringLen, object.FormatNumber(n, -1))
+	}
+	if str != "" && n > float64(maxStringLen/len(str)) {
+		return errStringTooLong()
+	}
+	return object.NewString(strings.Repeat(str, int(n)))
+}
+
+// padding returns the fill needed for str to be width runes long, repeating
+// pad (and cutting the last repetition) as needed.
+func padding(width float64, pad, str string) (string, object.Object) {
+	if math.IsNaN(width) || math.IsInf(width, 0) {
+		return "", newError("padding width must be finite, got %s", object.FormatNumber(width, -1))
+	}
+	if width <= float64(runeCount(str)) {
+		return "", nil
+	}
+	if pad == "" {
+		return "", newError("padding must not be empty")
+	}
+	if width > maxStringLen {
+		return "", errStringTooLong()
+	}
+
+	missing := int(width) - runeCount(str)
+	n := runeCount(pad)
+	times := (missing + n - 1) / n
+	if times > maxStringLen/len(pad) {
+		return "", errStringTooLong()
+	}
+	fill := strings.Repeat(pad, times)
+	return runeSlice(fill, 0, missing), nil
+}
+
+func errStringTooLong() object.Object {
+	return newError("string would be longer than %d bytes", maxStringLen)
+}
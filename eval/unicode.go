@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// graphemes splits s into user-perceived characters, following the main
+// rules for extended grapheme clusters from UAX #29: CR LF pairs, combining
+// marks, emoji modifiers and zero width joiner sequences, regional indicator
+// pairs (flags) and Hangul syllables built from jamo.
+//
+// golang.org/x/text has no segmenter, so this is an approximation built on
+// the general categories of package unicode rather than the grapheme break
+// properties. It differs from UAX #29 in that:
+//
+//   - prepended marks (Prepend) and Indic conjuncts (GB9c) are not joined;
+//   - every mark (Mn, Me, Mc) extends a cluster, which covers SpacingMark
+//     but also a few marks that aren't Grapheme_Extend;
+//   - Extended_Pictographic is taken as the So category plus U+1F000 to
+//     U+1FAFF, so some pictographs in other blocks don't join after a ZWJ.
+//
+// Text in most scripts, and the common emoji sequences, split as expected.
+func graphemes(s string) []string {
+	clusters := []string{}
+
+	start := 0
+	var prev rune = -1
+	ri := 0 // regional indicators in a row, to pair them
+
+	for i, r := range s {
+		if prev >= 0 && isGraphemeBreak(prev, r, ri) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+
+		if isRegionalIndicator(r) {
+			ri++
+		} else {
+			ri = 0
+		}
+		prev = r
+	}
+
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+const zwj = '\u200d' // zero width joiner
+
+func isGraphemeBreak(prev, next rune, ri int) bool {
+	switch {
+	case prev == '\r' && next == '\n':
+		return false
+
+	case isControl(prev) || isControl(next):
+		return true
+
+	case isHangulL(prev) && (isHangulL(next) || isHangulV(next) || isHangulLV(next) || isHangulLVT(next)):
+		return false
+
+	case (isHangulLV(prev) || isHangulV(prev)) && (isHangulV(next) || isHangulT(next)):
+		return false
+
+	case (isHangulLVT(prev) || isHangulT(prev)) && isHangulT(next):
+		return false
+
+	case isExtend(next):
+		return false
+
+	case prev == zwj && isPictographic(next):
+		return false
+
+	case isRegionalIndicator(prev) && isRegionalIndicator(next):
+		// Only pairs make flags
+		return ri%2 == 0
+
+	default:
+		return true
+	}
+}
+
+func isControl(r rune) bool {
+	return r == '\r' || r == '\n' || (unicode.IsControl(r) && r != zwj) || unicode.In(r, unicode.Zl, unicode.Zp)
+}
+
+func isExtend(r rune) bool {
+	return r == zwj ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // emoji skin tones
+}
+
+func isPictographic(r rune) bool {
+	return unicode.Is(unicode.So, r) || (r >= 0x1f000 && r <= 0x1faff)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isHangulL(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || (r >= 0xa960 && r <= 0xa97c)
+}
+
+func isHangulV(r rune) bool {
+	return (r >= 0x1160 && r <= 0x11a7) || (r >= 0xd7b0 && r <= 0xd7c6)
+}
+
+func isHangulT(r rune) bool {
+	return (r >= 0x11a8 && r <= 0x11ff) || (r >= 0xd7cb && r <= 0xd7fb)
+}
+
+// Precomposed syllables come in blocks of 28: the first of each block has no
+// trailing consonant (LV), the others have one (LVT).
+func isHangulLV(r rune) bool {
+	return r >= 0xac00 && r <= 0xd7a3 && (r-0xac00)%28 == 0
+}
+
+func isHangulLVT(r rune) bool {
+	return r >= 0xac00 && r <= 0xd7a3 && (r-0xac00)%28 != 0
+}
+
+// runeSlice returns the runes of s in [lo, hi), which must be valid rune
+// indexes, without converting the whole string.
+func runeSlice(s string, lo, hi int) string {
+	start, end := len(s), len(s)
+	i := 0
+	for pos := range s {
+		if i == lo {
+			start = pos
+		}
+		if i == hi {
+			end = pos
+			break
+		}
+		i++
+	}
+	return s[start:end]
+}
+
+func runeCount(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -1 +1,8 @@
 module github.com/geovanisouza92/geo
+
+go 1.16
+
+require (
+	golang.org/x/term v0.10.0
+	golang.org/x/text v0.3.8
+)
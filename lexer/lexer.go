@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"bufio"
 	"io"
+	"strings"
 	"text/scanner"
 
 	"github.com/geovanisouza92/geo/token"
@@ -11,17 +13,46 @@ type Lexer struct {
 	s scanner.Scanner
 
 	curr rune
+
+	// Some tokens are only known after the scanner went past them, like the
+	// range in 1..2, which it takes for a float
+	pending *token.Token
 }
 
 func New(in io.Reader) *Lexer {
 	var s scanner.Scanner
-	s.Init(in)
+	s.Init(skipShebang(in))
 	l := &Lexer{s: s}
 	l.readRune()
 	return l
 }
 
+// skipShebang drops a leading #! line, as in `#!/usr/bin/env geo`, so scripts
+// can be run directly. The line break is kept to keep line numbers right.
+func skipShebang(in io.Reader) io.Reader {
+	r := bufio.NewReader(in)
+	if b, _ := r.Peek(2); string(b) != "#!" {
+		return r
+	}
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return r
+		}
+		if c == '\n' {
+			r.UnreadByte()
+			return r
+		}
+	}
+}
+
 func (l *Lexer) NextToken() token.Token {
+	if l.pending != nil {
+		t := *l.pending
+		l.pending = nil
+		return t
+	}
+
 	var t token.Token
 
 	switch l.curr {
@@ -63,6 +94,14 @@ func (l *Lexer) NextToken() token.Token {
 		t = l.token(token.LBrace)
 	case '}':
 		t = l.token(token.RBrace)
+	case '#':
+		t = l.either('{', token.LSet, token.Error)
+	case '.':
+		if l.s.Peek() == '.' {
+			t = l.rangeToken()
+		} else {
+			t = l.token(token.Error)
+		}
 	case scanner.Ident:
 		p := l.s.Pos()
 		lit := l.s.TokenText()
@@ -81,6 +120,13 @@ func (l *Lexer) NextToken() token.Token {
 	case scanner.Int, scanner.Float:
 		p := l.s.Pos()
 		lit := l.s.TokenText()
+		if strings.HasSuffix(lit, ".") && l.s.Peek() == '.' {
+			// 1..2 is scanned as 1. and .2
+			lit = lit[:len(lit)-1]
+			p.Column--
+			r := l.rangeToken()
+			l.pending = &r
+		}
 		t = token.Token{
 			Type:    token.Number,
 			Literal: lit,
@@ -119,6 +165,20 @@ func (l *Lexer) token(ty token.TokenType) token.Token {
 	return token.Token{Type: ty, Literal: lit, Line: p.Line, Col: p.Column}
 }
 
+// rangeToken reads the rest of a range operator, with the scanner right
+// before its last dot. The scanner is not used for that, as it would take the
+// dot and the number after it for a float.
+func (l *Lexer) rangeToken() token.Token {
+	l.s.Next()
+	if l.s.Peek() == '<' {
+		l.s.Next()
+		p := l.s.Pos()
+		return token.Token{Type: token.RangeExcl, Literal: "..<", Line: p.Line, Col: p.Column}
+	}
+	p := l.s.Pos()
+	return token.Token{Type: token.Range, Literal: "..", Line: p.Line, Col: p.Column}
+}
+
 func (l *Lexer) either(lookAhead rune, option, alternative token.TokenType) token.Token {
 	p := l.s.Pos()
 	lit := l.s.TokenText()
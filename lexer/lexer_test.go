@@ -18,6 +18,8 @@ foo _foo f12 io! option? 1f
 [1] [1, 2]
 {} {"foo": "bar"} {"foo": "bar", "baz": "goo"}
 世界
+#{1}
+1..10 x..<n
 `
 
 	tt := []struct {
@@ -93,7 +95,16 @@ foo _foo f12 io! option? 1f
 		{token.String, "goo", 9, 44},
 		{token.RBrace, "}", 9, 47},
 		{token.Id, "世界", 10, 3},
-		{token.EOF, "", 11, 1},
+		{token.LSet, "#{", 11, 3},
+		{token.Number, "1", 11, 4},
+		{token.RBrace, "}", 11, 5},
+		{token.Number, "1", 12, 2},
+		{token.Range, "..", 12, 4},
+		{token.Number, "10", 12, 6},
+		{token.Id, "x", 12, 8},
+		{token.RangeExcl, "..<", 12, 11},
+		{token.Id, "n", 12, 12},
+		{token.EOF, "", 13, 1},
 	}
 
 	l := New(strings.NewReader(input))
@@ -116,3 +127,12 @@ foo _foo f12 io! option? 1f
 		})
 	}
 }
+
+func TestShebang(t *testing.T) {
+	l := New(strings.NewReader("#!/usr/bin/env geo\nlet x"))
+
+	tok := l.NextToken()
+	if tok.Type != token.Let || tok.Line != 2 || tok.Col != 4 {
+		t.Errorf("first token should be let at 2:4; got %s %q at %d:%d", tok.Type, tok.Literal, tok.Line, tok.Col)
+	}
+}
@@ -0,0 +1,109 @@
+package object
+
+// Equal reports whether a and b are structurally equal: numbers, booleans and
+// strings are compared by value, arrays and tuples element by element, hashes
+// pair by pair and sets element by element, the last two regardless of
+// insertion order. Every other object is compared by identity.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+// equal keeps track of the array/hash pairs being compared so a value that
+// (directly or not) contains itself does not recurse forever. A pair already
+// under comparison is assumed equal; any real difference is found elsewhere.
+func equal(a, b Object, seen map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Number:
+		return a.Value == b.(*Number).Value
+
+	case *Bool:
+		return a.Value == b.(*Bool).Value
+
+	case *String:
+		return a.Value == b.(*String).Value
+
+	case *Null:
+		return true
+
+	case *Array:
+		b := b.(*Array)
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.At(i), b.At(i), seen) {
+				return false
+			}
+		}
+		return true
+
+	case *Hash:
+		b := b.(*Hash)
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+
+		for _, p := range a.Pairs() {
+			other, ok := b.Get(p.Key)
+			if !ok || !equal(p.Value, other, seen) {
+				return false
+			}
+		}
+		return true
+
+	case *Tuple:
+		b := b.(*Tuple)
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.At(i), b.At(i), seen) {
+				return false
+			}
+		}
+		return true
+
+	case *Set:
+		// Elements are keys, so their equality is settled by the lookup
+		b := b.(*Set)
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, elm := range a.Elements() {
+			if !b.Has(elm) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatNumber prints v the way numbers print in geo, with precision digits
+// after the decimal point. When precision is negative, v prints with as many
+// digits as needed to read back the same value, and only very large or very
+// small numbers use exponents.
+func FormatNumber(v float64, precision int) string {
+	switch {
+	case math.IsNaN(v):
+		return "nan"
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	case precision >= 0:
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Format prints obj the way String does, but with the numbers in it printed
+// with the given precision (see FormatNumber).
+func Format(obj Object, precision int) string {
+	var b strings.Builder
+	writeObject(&b, obj, precision)
+	return b.String()
+}
+
+func writeObject(b *strings.Builder, obj Object, precision int) {
+	switch obj := obj.(type) {
+	case *Number:
+		b.WriteString(FormatNumber(obj.Value, precision))
+	case *Return:
+		writeObject(b, obj.Value, precision)
+	case *Array:
+		writeList(b, "[", obj.Elements(), "]", precision)
+	case *Set:
+		writeList(b, "#{", obj.Elements(), "}", precision)
+	case *Tuple:
+		if len(obj.elements) == 1 {
+			writeList(b, "(", obj.elements, ",)", precision)
+		} else {
+			writeList(b, "(", obj.elements, ")", precision)
+		}
+	case *Hash:
+		b.WriteString("{")
+		for i, pair := range obj.Pairs() {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeObject(b, pair.Key, precision)
+			b.WriteString(": ")
+			writeObject(b, pair.Value, precision)
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString(obj.String())
+	}
+}
+
+func writeList(b *strings.Builder, open string, elms []Object, close string, precision int) {
+	b.WriteString(open)
+	for i, elm := range elms {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeObject(b, elm, precision)
+	}
+	b.WriteString(close)
+}
@@ -0,0 +1,209 @@
+package object
+
+import "math/bits"
+
+// hamt is a persistent hash array mapped trie. Each level consumes 5 bits of
+// the hash; keys whose hashes are fully equal share a leaf and are told apart
+// by Equal. Like vector, updates only copy the path to the touched leaf.
+type hamt struct {
+	root  *hnode
+	count int
+}
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+type hentry struct {
+	key   Object
+	value Object
+	seq   int // position in the insertion order
+}
+
+// hnode is a bitmap-indexed branch; children are either *hnode or *hleaf.
+type hnode struct {
+	bitmap   uint32
+	children []interface{}
+}
+
+type hleaf struct {
+	hash    uint64
+	entries []hentry
+}
+
+var emptyHamt = hamt{root: &hnode{}}
+
+// hashOf spreads a HashKey over the 64 bits so the trie stays balanced even
+// for keys like small integers.
+func hashOf(k HashKey) uint64 {
+	h := k.Value ^ uint64(k.Type)*0x9e3779b97f4a7c15
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+func (n *hnode) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (m hamt) get(hash uint64, key Object) (hentry, bool) {
+	node := m.root
+	for shift := uint(0); ; shift += hamtBits {
+		bit := uint32(1) << ((hash >> shift) & hamtMask)
+		if node.bitmap&bit == 0 {
+			return hentry{}, false
+		}
+
+		switch child := node.children[node.index(bit)].(type) {
+		case *hleaf:
+			if child.hash == hash {
+				for _, e := range child.entries {
+					if Equal(e.key, key) {
+						return e, true
+					}
+				}
+			}
+			return hentry{}, false
+
+		case *hnode:
+			node = child
+		}
+	}
+}
+
+func (m hamt) assoc(hash uint64, e hentry) hamt {
+	root, added := m.root.assoc(0, hash, e)
+	count := m.count
+	if added {
+		count++
+	}
+	return hamt{root: root, count: count}
+}
+
+func (n *hnode) assoc(shift uint, hash uint64, e hentry) (*hnode, bool) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+	idx := n.index(bit)
+
+	if n.bitmap&bit == 0 {
+		children := make([]interface{}, len(n.children)+1)
+		copy(children, n.children[:idx])
+		children[idx] = &hleaf{hash: hash, entries: []hentry{e}}
+		copy(children[idx+1:], n.children[idx:])
+		return &hnode{bitmap: n.bitmap | bit, children: children}, true
+	}
+
+	var sub interface{}
+	var added bool
+
+	switch child := n.children[idx].(type) {
+	case *hleaf:
+		if child.hash == hash {
+			sub, added = child.assoc(e)
+		} else {
+			sub, added = mergeLeaves(shift+hamtBits, child, &hleaf{hash: hash, entries: []hentry{e}}), true
+		}
+
+	case *hnode:
+		sub, added = child.assoc(shift+hamtBits, hash, e)
+	}
+
+	children := make([]interface{}, len(n.children))
+	copy(children, n.children)
+	children[idx] = sub
+	return &hnode{bitmap: n.bitmap, children: children}, added
+}
+
+func (l *hleaf) assoc(e hentry) (*hleaf, bool) {
+	entries := make([]hentry, len(l.entries), len(l.entries)+1)
+	copy(entries, l.entries)
+
+	for i := range entries {
+		if Equal(entries[i].key, e.key) {
+			entries[i] = e
+			return &hleaf{hash: l.hash, entries: entries}, false
+		}
+	}
+
+	return &hleaf{hash: l.hash, entries: append(entries, e)}, true
+}
+
+// mergeLeaves builds the branches needed to tell apart two leaves with
+// different hashes that fell on the same slot.
+func mergeLeaves(shift uint, a, b *hleaf) *hnode {
+	ia := (a.hash >> shift) & hamtMask
+	ib := (b.hash >> shift) & hamtMask
+
+	if ia == ib {
+		return &hnode{bitmap: 1 << ia, children: []interface{}{mergeLeaves(shift+hamtBits, a, b)}}
+	}
+	if ia > ib {
+		a, b = b, a
+	}
+	return &hnode{bitmap: 1<<ia | 1<<ib, children: []interface{}{a, b}}
+}
+
+func (m hamt) dissoc(hash uint64, key Object) hamt {
+	root, removed := m.root.dissoc(0, hash, key)
+	if !removed {
+		return m
+	}
+	return hamt{root: root, count: m.count - 1}
+}
+
+func (n *hnode) dissoc(shift uint, hash uint64, key Object) (*hnode, bool) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	idx := n.index(bit)
+
+	var sub interface{}
+	var empty bool
+
+	switch child := n.children[idx].(type) {
+	case *hleaf:
+		if child.hash != hash {
+			return n, false
+		}
+		leaf, removed := child.dissoc(key)
+		if !removed {
+			return n, false
+		}
+		sub, empty = leaf, len(leaf.entries) == 0
+
+	case *hnode:
+		node, removed := child.dissoc(shift+hamtBits, hash, key)
+		if !removed {
+			return n, false
+		}
+		sub, empty = node, node.bitmap == 0
+	}
+
+	if empty {
+		children := make([]interface{}, 0, len(n.children)-1)
+		children = append(children, n.children[:idx]...)
+		children = append(children, n.children[idx+1:]...)
+		return &hnode{bitmap: n.bitmap &^ bit, children: children}, true
+	}
+
+	children := make([]interface{}, len(n.children))
+	copy(children, n.children)
+	children[idx] = sub
+	return &hnode{bitmap: n.bitmap, children: children}, true
+}
+
+func (l *hleaf) dissoc(key Object) (*hleaf, bool) {
+	for i, e := range l.entries {
+		if Equal(e.key, key) {
+			entries := make([]hentry, 0, len(l.entries)-1)
+			entries = append(entries, l.entries[:i]...)
+			entries = append(entries, l.entries[i+1:]...)
+			return &hleaf{hash: l.hash, entries: entries}, true
+		}
+	}
+	return l, false
+}
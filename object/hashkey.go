@@ -0,0 +1,73 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+)
+
+// hashKeyOf computes the key of composite values from the keys of what they
+// hold. Values that are not Hashable (like functions) only contribute their
+// type: the key is just a hint of where to look, equality has the final word.
+func hashKeyOf(obj Object, seen map[Object]bool) HashKey {
+	switch obj := obj.(type) {
+	case *Array:
+		h := fnv.New64a()
+		if !seen[obj] {
+			seen[obj] = true
+			for _, elm := range obj.Elements() {
+				writeHashKey(h, hashKeyOf(elm, seen))
+			}
+			delete(seen, obj)
+		}
+		return HashKey{obj.Type(), h.Sum64()}
+
+	case *Tuple:
+		h := fnv.New64a()
+		if !seen[obj] {
+			seen[obj] = true
+			for _, elm := range obj.elements {
+				writeHashKey(h, hashKeyOf(elm, seen))
+			}
+			delete(seen, obj)
+		}
+		return HashKey{obj.Type(), h.Sum64()}
+
+	case *Set:
+		var sum uint64
+		for _, elm := range obj.Elements() {
+			h := fnv.New64a()
+			writeHashKey(h, hashKeyOf(elm, seen))
+			sum += h.Sum64()
+		}
+		return HashKey{obj.Type(), sum}
+
+	case *Hash:
+		// Pairs are summed up, so the order they are visited does not matter
+		var sum uint64
+		if !seen[obj] {
+			seen[obj] = true
+			for _, pair := range obj.Pairs() {
+				h := fnv.New64a()
+				writeHashKey(h, hashKeyOf(pair.Key, seen))
+				writeHashKey(h, hashKeyOf(pair.Value, seen))
+				sum += h.Sum64()
+			}
+			delete(seen, obj)
+		}
+		return HashKey{obj.Type(), sum}
+
+	case Hashable:
+		return obj.HashKey()
+
+	default:
+		return HashKey{Type: obj.Type()}
+	}
+}
+
+func writeHashKey(w io.Writer, k HashKey) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(k.Type))
+	binary.LittleEndian.PutUint64(buf[8:], k.Value)
+	w.Write(buf[:])
+}
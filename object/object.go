@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"sort"
 	"strings"
 
 	"github.com/geovanisouza92/geo/ast"
@@ -35,7 +36,7 @@ func NewNumber(value float64) *Number {
 }
 
 func (n *Number) Type() ObjectType { return TypeNumber }
-func (n *Number) String() string   { return fmt.Sprintf("%v", n.Value) }
+func (n *Number) String() string   { return FormatNumber(n.Value, -1) }
 func (n *Number) HashKey() HashKey { return n.hashKey }
 
 type Bool struct {
@@ -74,53 +75,276 @@ func (s *String) Type() ObjectType { return TypeString }
 func (s *String) String() string   { return s.Value }
 func (s *String) HashKey() HashKey { return s.hashKey }
 
+// Array is an immutable sequence backed by a persistent vector. Every update
+// returns a new array that shares most of its structure with the original,
+// so building arrays one element at a time or walking them with tail is
+// cheap. An array may be a view over a range of its vector, which makes
+// slicing O(1).
 type Array struct {
-	Elements []Object
+	vec        vector
+	start, end int
+	hashKey    *HashKey
+}
+
+func NewArray(elms []Object) *Array {
+	vec := emptyVector
+	for _, elm := range elms {
+		vec = vec.push(elm)
+	}
+	return &Array{vec: vec, end: vec.count}
 }
 
 func (a *Array) Type() ObjectType { return TypeArray }
 
-func (a *Array) String() string {
-	var b bytes.Buffer
+// HashKey combines the keys of every element, so arrays holding equal values
+// land on the same bucket. Arrays are never mutated once built, so the key is
+// computed only once.
+func (a *Array) HashKey() HashKey {
+	if a.hashKey == nil {
+		k := hashKeyOf(a, map[Object]bool{})
+		a.hashKey = &k
+	}
+	return *a.hashKey
+}
+
+func (a *Array) Len() int { return a.end - a.start }
+
+// At returns the element at index i, which must be in [0, Len()).
+func (a *Array) At(i int) Object { return a.vec.get(a.start + i) }
+
+// Elements returns a fresh slice with all elements of the array.
+func (a *Array) Elements() []Object {
+	elms := make([]Object, a.Len())
+	for i := range elms {
+		elms[i] = a.At(i)
+	}
+	return elms
+}
 
-	elms := []string{}
-	for _, elm := range a.Elements {
-		elms = append(elms, elm.String())
+// Push returns a new array with obj appended.
+func (a *Array) Push(obj Object) *Array {
+	vec := a.vec
+	if a.end == vec.count {
+		vec = vec.push(obj)
+	} else {
+		// This is a view that stops before the end of the vector, so the
+		// next slot is taken by elements that are not part of it
+		vec = vec.assoc(a.end, obj)
 	}
+	return &Array{vec: vec, start: a.start, end: a.end + 1}
+}
 
-	b.WriteString("[")
-	b.WriteString(strings.Join(elms, ", "))
-	b.WriteString("]")
+// Assoc returns a new array with the element at index i, which must be in
+// [0, Len()), replaced by obj.
+func (a *Array) Assoc(i int, obj Object) *Array {
+	return &Array{vec: a.vec.assoc(a.start+i, obj), start: a.start, end: a.end}
+}
 
-	return b.String()
+// Slice returns the elements in [lo, hi), with 0 <= lo <= hi <= Len(),
+// sharing the storage of the original array.
+func (a *Array) Slice(lo, hi int) *Array {
+	return &Array{vec: a.vec, start: a.start + lo, end: a.start + hi}
 }
 
+func (a *Array) String() string { return Format(a, -1) }
+
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
+// Hash is an immutable map backed by a persistent hash trie, remembering the
+// order keys were first inserted. Keys are told apart by structural
+// equality, so colliding keys (like 1 and 1.5, or two different arrays) never
+// overwrite each other.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	m       hamt
+	order   vector // keys by insertion order, nil where a key was removed
+	hashKey *HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{m: emptyHamt, order: emptyVector}
 }
 
 func (h *Hash) Type() ObjectType { return TypeHash }
 
-func (h *Hash) String() string {
-	var b bytes.Buffer
+// HashKey does not depend on the order of the pairs, just like equality.
+func (h *Hash) HashKey() HashKey {
+	if h.hashKey == nil {
+		k := hashKeyOf(h, map[Object]bool{})
+		h.hashKey = &k
+	}
+	return *h.hashKey
+}
+
+func (h *Hash) Len() int { return h.m.count }
 
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+// Get returns the value associated with key. Keys that are not Hashable are
+// never found.
+func (h *Hash) Get(key Object) (Object, bool) {
+	k, ok := key.(Hashable)
+	if !ok {
+		return nil, false
 	}
 
-	b.WriteString("{")
-	b.WriteString(strings.Join(pairs, ", "))
-	b.WriteString("}")
+	e, ok := h.m.get(hashOf(k.HashKey()), key)
+	return e.value, ok
+}
 
-	return b.String()
+// Assoc returns a new hash with value associated with key, which must be
+// Hashable. A key that is already present keeps its original position.
+func (h *Hash) Assoc(key, value Object) *Hash {
+	hash := hashOf(key.(Hashable).HashKey())
+
+	if e, ok := h.m.get(hash, key); ok {
+		m := h.m.assoc(hash, hentry{key: e.key, value: value, seq: e.seq})
+		return &Hash{m: m, order: h.order}
+	}
+
+	m := h.m.assoc(hash, hentry{key: key, value: value, seq: h.order.count})
+	return &Hash{m: m, order: h.order.push(key)}
+}
+
+// Dissoc returns a new hash without key.
+func (h *Hash) Dissoc(key Object) *Hash {
+	k, ok := key.(Hashable)
+	if !ok {
+		return h
+	}
+
+	hash := hashOf(k.HashKey())
+	e, ok := h.m.get(hash, key)
+	if !ok {
+		return h
+	}
+
+	d := &Hash{m: h.m.dissoc(hash, key), order: h.order.assoc(e.seq, nil)}
+
+	// Too many holes left in the order, start over from the remaining pairs
+	if d.order.count > 2*d.m.count+vecWidth {
+		compact := NewHash()
+		for _, pair := range d.Pairs() {
+			compact = compact.Assoc(pair.Key, pair.Value)
+		}
+		return compact
+	}
+
+	return d
+}
+
+// Pairs returns the pairs in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, h.Len())
+	for i := 0; i < h.order.count; i++ {
+		key := h.order.get(i)
+		if key == nil {
+			continue
+		}
+		e, _ := h.m.get(hashOf(key.(Hashable).HashKey()), key)
+		pairs = append(pairs, HashPair{Key: e.key, Value: e.value})
+	}
+	return pairs
+}
+
+func (h *Hash) String() string { return Format(h, -1) }
+
+// Set is an immutable collection of distinct values, kept in insertion
+// order. It shares the hash trie used by Hash, mapping each element to
+// itself.
+type Set struct {
+	items   *Hash
+	hashKey *HashKey
+}
+
+func NewSet(elms []Object) *Set {
+	s := &Set{items: NewHash()}
+	for _, elm := range elms {
+		s = s.Add(elm)
+	}
+	return s
+}
+
+func (s *Set) Type() ObjectType { return TypeSet }
+
+// HashKey does not depend on the order of the elements, just like equality.
+func (s *Set) HashKey() HashKey {
+	if s.hashKey == nil {
+		k := hashKeyOf(s, map[Object]bool{})
+		s.hashKey = &k
+	}
+	return *s.hashKey
+}
+
+func (s *Set) Len() int { return s.items.Len() }
+
+func (s *Set) Has(elm Object) bool {
+	_, ok := s.items.Get(elm)
+	return ok
+}
+
+// Add returns a new set including elm, which must be Hashable.
+func (s *Set) Add(elm Object) *Set {
+	if s.Has(elm) {
+		return s
+	}
+	return &Set{items: s.items.Assoc(elm, elm)}
+}
+
+// Remove returns a new set without elm.
+func (s *Set) Remove(elm Object) *Set {
+	if !s.Has(elm) {
+		return s
+	}
+	return &Set{items: s.items.Dissoc(elm)}
+}
+
+// Elements returns the elements in insertion order.
+func (s *Set) Elements() []Object {
+	pairs := s.items.Pairs()
+	elms := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		elms[i] = pair.Key
+	}
+	return elms
+}
+
+func (s *Set) String() string { return Format(s, -1) }
+
+// Tuple is an immutable sequence of fixed size.
+type Tuple struct {
+	elements []Object
+	hashKey  *HashKey
+}
+
+func NewTuple(elms []Object) *Tuple {
+	return &Tuple{elements: elms}
+}
+
+func (t *Tuple) Type() ObjectType { return TypeTuple }
+
+func (t *Tuple) HashKey() HashKey {
+	if t.hashKey == nil {
+		k := hashKeyOf(t, map[Object]bool{})
+		t.hashKey = &k
+	}
+	return *t.hashKey
+}
+
+func (t *Tuple) Len() int { return len(t.elements) }
+
+// At returns the element at index i, which must be in [0, Len()).
+func (t *Tuple) At(i int) Object { return t.elements[i] }
+
+// Elements returns a fresh slice with all elements of the tuple.
+func (t *Tuple) Elements() []Object {
+	elms := make([]Object, len(t.elements))
+	copy(elms, t.elements)
+	return elms
 }
 
+func (t *Tuple) String() string { return Format(t, -1) }
+
 type Null struct {
 }
 
@@ -136,6 +360,10 @@ func (r *Return) String() string   { return r.Value.String() }
 
 type Error struct {
 	Message error
+
+	// Where the error was raised, or zero when unknown
+	Line int
+	Col  int
 }
 
 func (e *Error) Type() ObjectType { return TypeError }
@@ -173,6 +401,17 @@ func (e *Scope) Set(name string, val Object) Object {
 	return val
 }
 
+// Names returns the names defined in the scope, leaving out its parents,
+// sorted.
+func (e *Scope) Names() []string {
+	names := make([]string, 0, len(e.internal))
+	for name := range e.internal {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type Fn struct {
 	Params []*ast.Id
 	Body   *ast.BlockStatement
@@ -201,8 +440,10 @@ func (f *Fn) String() string {
 type BuiltinImpl func(...Object) Object
 
 type Builtin struct {
-	// NOTE: Maybe declare other fields, like Doc
-	Name   string
+	Name string
+	// Doc tells what the builtin does, starting with how it's called, as in
+	// `len(x) is ...`.
+	Doc    string
 	Params []ObjectType
 	Impl   BuiltinImpl
 }
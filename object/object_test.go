@@ -1,6 +1,8 @@
 package object
 
 import (
+	"fmt"
+	"math"
 	"testing"
 )
 
@@ -17,6 +19,40 @@ func TestHashKeys(t *testing.T) {
 			t.Errorf("hash key for hello1 and diff should be different; got hello1(%v) and diff(%v)", hello1.HashKey(), diff.HashKey())
 		}
 	})
+
+	t.Run("composite hash keys", func(t *testing.T) {
+		pair1 := NewArray([]Object{NewNumber(1), NewString("a")})
+		pair2 := NewArray([]Object{NewNumber(1), NewString("a")})
+		diff := NewArray([]Object{NewString("a"), NewNumber(1)})
+
+		if pair1.HashKey() != pair2.HashKey() {
+			t.Errorf("hash key for pair1 and pair2 should be equal; got pair1(%v) and pair2(%v)", pair1.HashKey(), pair2.HashKey())
+		}
+		if pair1.HashKey() == diff.HashKey() {
+			t.Errorf("hash key for pair1 and diff should be different; got pair1(%v) and diff(%v)", pair1.HashKey(), diff.HashKey())
+		}
+
+		h1 := NewHash().Assoc(NewString("x"), NewNumber(1)).Assoc(NewString("y"), NewNumber(2))
+		h2 := NewHash().Assoc(NewString("y"), NewNumber(2)).Assoc(NewString("x"), NewNumber(1))
+
+		if h1.HashKey() != h2.HashKey() {
+			t.Errorf("hash key for h1 and h2 should be equal; got h1(%v) and h2(%v)", h1.HashKey(), h2.HashKey())
+		}
+	})
+
+	t.Run("colliding hash keys", func(t *testing.T) {
+		h := NewHash().Assoc(NewNumber(1), NewString("one")).Assoc(NewNumber(1.5), NewString("one and a half"))
+
+		if h.Len() != 2 {
+			t.Errorf("hash should have 2 pairs; got %d", h.Len())
+		}
+		if v, _ := h.Get(NewNumber(1)); v.String() != "one" {
+			t.Errorf("value for 1 should be %q; got %q", "one", v.String())
+		}
+		if v, _ := h.Get(NewNumber(1.5)); v.String() != "one and a half" {
+			t.Errorf("value for 1.5 should be %q; got %q", "one and a half", v.String())
+		}
+	})
 }
 
 func TestObjectTypeToString(t *testing.T) {
@@ -26,6 +62,7 @@ func TestObjectTypeToString(t *testing.T) {
 	}{
 		{TypeArray | TypeString, "TypeString, TypeArray"},
 		{TypeString | TypeArray, "TypeString, TypeArray"},
+		{TypeTuple | TypeSet | TypeHash, "TypeHash, TypeSet, TypeTuple"},
 	}
 
 	for _, tc := range tt {
@@ -38,3 +75,302 @@ func TestObjectTypeToString(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatNumber(t *testing.T) {
+	tt := []struct {
+		value     float64
+		precision int
+		str       string
+	}{
+		{1, -1, "1"},
+		{-2.5, -1, "-2.5"},
+		{1e6, -1, "1000000"},
+		{123456789012, -1, "123456789012"},
+		{1e21, -1, "1e+21"},
+		{0.000001, -1, "0.000001"},
+		{1e-7, -1, "1e-07"},
+		{0, -1, "0"},
+		{1.0 / 3, 2, "0.33"},
+		{2, 3, "2.000"},
+		{math.Inf(1), 2, "inf"},
+		{math.Inf(-1), -1, "-inf"},
+		{math.NaN(), -1, "nan"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.str, func(t *testing.T) {
+			if actual := FormatNumber(tc.value, tc.precision); actual != tc.str {
+				t.Errorf("number should format as %q; got %q", tc.str, actual)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	h := NewHash().Assoc(NewNumber(1), NewTuple([]Object{NewNumber(0.5)}))
+	tt := []struct {
+		value     Object
+		precision int
+		str       string
+	}{
+		{NewNumber(2), 1, "2.0"},
+		{NewArray([]Object{NewNumber(1), NewString("a")}), 2, "[1.00, a]"},
+		{h, 1, "{1.0: (0.5,)}"},
+		{h, -1, "{1: (0.5,)}"},
+		{NewSet([]Object{NewTuple([]Object{NewNumber(1), NewNumber(2)})}), 0, "#{(1, 2)}"},
+		{&Return{Value: NewNumber(1)}, 3, "1.000"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.str, func(t *testing.T) {
+			if actual := Format(tc.value, tc.precision); actual != tc.str {
+				t.Errorf("value should format as %q; got %q", tc.str, actual)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	cyclic := func() *Array {
+		// Arrays can't be changed from geo code, so this only happens here
+		a := NewArray([]Object{NewNumber(1), nil})
+		a.vec.tail[1] = a
+		return a
+	}
+
+	tt := []struct {
+		name  string
+		left  Object
+		right Object
+		equal bool
+	}{
+		{"numbers", NewNumber(1), NewNumber(1), true},
+		{"different numbers", NewNumber(1), NewNumber(2), false},
+		{"strings", NewString("foo"), NewString("foo"), true},
+		{"different types", NewNumber(1), NewString("1"), false},
+		{"arrays", NewArray([]Object{NewNumber(1)}), NewArray([]Object{NewNumber(1)}), true},
+		{"cyclic arrays", cyclic(), cyclic(), true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Equal(tc.left, tc.right); actual != tc.equal {
+				t.Errorf("equality of %s and %s should be %t; got %t", tc.left.Type(), tc.right.Type(), tc.equal, actual)
+			}
+		})
+	}
+}
+
+func TestArray(t *testing.T) {
+	t.Run("push and at", func(t *testing.T) {
+		// Enough elements to need a few levels in the trie
+		const n = 40000
+
+		versions := []*Array{}
+		a := NewArray(nil)
+		for i := 0; i < n; i++ {
+			if i%1000 == 0 {
+				versions = append(versions, a)
+			}
+			a = a.Push(NewNumber(float64(i)))
+		}
+
+		if a.Len() != n {
+			t.Errorf("array should have %d elements; got %d", n, a.Len())
+		}
+		for i := 0; i < n; i++ {
+			if v := a.At(i).(*Number).Value; v != float64(i) {
+				t.Fatalf("element at %d should be %d; got %v", i, i, v)
+			}
+		}
+		for i, v := range versions {
+			if v.Len() != i*1000 {
+				t.Errorf("older versions should not change; got %d elements instead of %d", v.Len(), i*1000)
+			}
+		}
+	})
+
+	t.Run("assoc", func(t *testing.T) {
+		a := NewArray(nil)
+		for i := 0; i < 100; i++ {
+			a = a.Push(NewNumber(float64(i)))
+		}
+
+		b := a.Assoc(3, NewString("three")).Assoc(99, NewString("ninety-nine"))
+
+		if a.At(3).String() != "3" || a.At(99).String() != "99" {
+			t.Errorf("assoc should not change the original array; got %s and %s", a.At(3), a.At(99))
+		}
+		if b.At(3).String() != "three" || b.At(99).String() != "ninety-nine" {
+			t.Errorf("assoc should replace elements; got %s and %s", b.At(3), b.At(99))
+		}
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		a := NewArray([]Object{NewNumber(1), NewNumber(2), NewNumber(3), NewNumber(4)})
+
+		s := a.Slice(1, 3)
+		if s.String() != "[2, 3]" {
+			t.Errorf("slice should be %q; got %q", "[2, 3]", s.String())
+		}
+
+		p := s.Push(NewNumber(5))
+		if p.String() != "[2, 3, 5]" {
+			t.Errorf("push on a slice should be %q; got %q", "[2, 3, 5]", p.String())
+		}
+		if a.String() != "[1, 2, 3, 4]" {
+			t.Errorf("push on a slice should not change the original array; got %q", a.String())
+		}
+	})
+}
+
+func TestHash(t *testing.T) {
+	t.Run("assoc and get", func(t *testing.T) {
+		const n = 5000
+
+		h := NewHash()
+		for i := 0; i < n; i++ {
+			h = h.Assoc(NewNumber(float64(i)), NewNumber(float64(i*2)))
+		}
+
+		if h.Len() != n {
+			t.Errorf("hash should have %d pairs; got %d", n, h.Len())
+		}
+		for i := 0; i < n; i++ {
+			v, ok := h.Get(NewNumber(float64(i)))
+			if !ok || v.(*Number).Value != float64(i*2) {
+				t.Fatalf("value for %d should be %d; got %v", i, i*2, v)
+			}
+		}
+		if _, ok := h.Get(NewNumber(n)); ok {
+			t.Errorf("value for %d should not exist", n)
+		}
+	})
+
+	t.Run("dissoc", func(t *testing.T) {
+		const n = 1000
+
+		h := NewHash()
+		for i := 0; i < n; i++ {
+			h = h.Assoc(NewNumber(float64(i)), True)
+		}
+
+		d := h
+		for i := 0; i < n; i += 2 {
+			d = d.Dissoc(NewNumber(float64(i)))
+		}
+
+		if h.Len() != n {
+			t.Errorf("dissoc should not change the original hash; got %d pairs", h.Len())
+		}
+		if d.Len() != n/2 {
+			t.Errorf("hash should have %d pairs; got %d", n/2, d.Len())
+		}
+		for i, pair := range d.Pairs() {
+			if v := pair.Key.(*Number).Value; v != float64(i*2+1) {
+				t.Fatalf("pair %d should have key %d; got %v", i, i*2+1, v)
+			}
+		}
+	})
+
+	t.Run("insertion order", func(t *testing.T) {
+		h := NewHash().
+			Assoc(NewString("c"), NewNumber(1)).
+			Assoc(NewString("a"), NewNumber(2)).
+			Assoc(NewString("b"), NewNumber(3)).
+			Assoc(NewString("c"), NewNumber(4)).
+			Dissoc(NewString("a")).
+			Assoc(NewString("a"), NewNumber(5))
+
+		if h.String() != "{c: 4, b: 3, a: 5}" {
+			t.Errorf("hash should be %q; got %q", "{c: 4, b: 3, a: 5}", h.String())
+		}
+	})
+}
+
+var (
+	benchmarkSizes = []int{100, 1000, 10000}
+	True           = NewBool(true)
+)
+
+func BenchmarkPush(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("copying/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				elms := []Object{}
+				for j := 0; j < n; j++ {
+					next := make([]Object, len(elms)+1)
+					copy(next, elms)
+					next[len(elms)] = True
+					elms = next
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("persistent/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				a := NewArray(nil)
+				for j := 0; j < n; j++ {
+					a = a.Push(True)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTail(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		elms := make([]Object, n)
+		for i := range elms {
+			elms[i] = True
+		}
+
+		b.Run(fmt.Sprintf("copying/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for rest := elms; len(rest) > 0; {
+					next := make([]Object, len(rest)-1)
+					copy(next, rest[1:])
+					rest = next
+				}
+			}
+		})
+
+		a := NewArray(elms)
+		b.Run(fmt.Sprintf("persistent/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for rest := a; rest.Len() > 0; {
+					rest = rest.Slice(1, rest.Len())
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAssoc(b *testing.B) {
+	// Copying a Go map for every insertion is too slow for the larger sizes
+	for _, n := range benchmarkSizes[:2] {
+		b.Run(fmt.Sprintf("copying/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := map[HashKey]HashPair{}
+				for j := 0; j < n; j++ {
+					key := NewNumber(float64(j))
+					next := make(map[HashKey]HashPair, len(m)+1)
+					for k, v := range m {
+						next[k] = v
+					}
+					next[key.HashKey()] = HashPair{Key: key, Value: True}
+					m = next
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("persistent/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h := NewHash()
+				for j := 0; j < n; j++ {
+					h = h.Assoc(NewNumber(float64(j)), True)
+				}
+			}
+		})
+	}
+}
@@ -20,9 +20,12 @@ const (
 	TypeReturn
 	TypeFn
 	TypeBuiltin
+	TypeSet
+	TypeTuple
+	TypeSeq
 )
 
-const TypeAny = TypeError | TypeNumber | TypeBool | TypeString | TypeArray | TypeHash | TypeNull | TypeReturn | TypeFn | TypeBuiltin
+const TypeAny = TypeError | TypeNumber | TypeBool | TypeString | TypeArray | TypeHash | TypeNull | TypeReturn | TypeFn | TypeBuiltin | TypeSet | TypeTuple | TypeSeq
 
 type ByObjectType []ObjectType
 
@@ -41,6 +44,9 @@ var objectTypes = []ObjectType{
 	TypeReturn,
 	TypeFn,
 	TypeBuiltin,
+	TypeSet,
+	TypeTuple,
+	TypeSeq,
 	// TypeAny,
 }
 
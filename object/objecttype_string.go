@@ -4,44 +4,46 @@ package object
 
 import "strconv"
 
-const (
-	_ObjectType_name_0 = "TypeErrorTypeNumber"
-	_ObjectType_name_1 = "TypeBool"
-	_ObjectType_name_2 = "TypeString"
-	_ObjectType_name_3 = "TypeArray"
-	_ObjectType_name_4 = "TypeHash"
-	_ObjectType_name_5 = "TypeNull"
-	_ObjectType_name_6 = "TypeReturn"
-	_ObjectType_name_7 = "TypeFn"
-	_ObjectType_name_8 = "TypeBuiltin"
-)
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[TypeError-1]
+	_ = x[TypeNumber-2]
+	_ = x[TypeBool-4]
+	_ = x[TypeString-8]
+	_ = x[TypeArray-16]
+	_ = x[TypeHash-32]
+	_ = x[TypeNull-64]
+	_ = x[TypeReturn-128]
+	_ = x[TypeFn-256]
+	_ = x[TypeBuiltin-512]
+	_ = x[TypeSet-1024]
+	_ = x[TypeTuple-2048]
+	_ = x[TypeSeq-4096]
+}
+
+const _ObjectType_name = "TypeErrorTypeNumberTypeBoolTypeStringTypeArrayTypeHashTypeNullTypeReturnTypeFnTypeBuiltinTypeSetTypeTupleTypeSeq"
 
-var (
-	_ObjectType_index_0 = [...]uint8{0, 9, 19}
-)
+var _ObjectType_map = map[ObjectType]string{
+	1:    _ObjectType_name[0:9],
+	2:    _ObjectType_name[9:19],
+	4:    _ObjectType_name[19:27],
+	8:    _ObjectType_name[27:37],
+	16:   _ObjectType_name[37:46],
+	32:   _ObjectType_name[46:54],
+	64:   _ObjectType_name[54:62],
+	128:  _ObjectType_name[62:72],
+	256:  _ObjectType_name[72:78],
+	512:  _ObjectType_name[78:89],
+	1024: _ObjectType_name[89:96],
+	2048: _ObjectType_name[96:105],
+	4096: _ObjectType_name[105:112],
+}
 
 func (i ObjectType) String() string {
-	switch {
-	case 1 <= i && i <= 2:
-		i -= 1
-		return _ObjectType_name_0[_ObjectType_index_0[i]:_ObjectType_index_0[i+1]]
-	case i == 4:
-		return _ObjectType_name_1
-	case i == 8:
-		return _ObjectType_name_2
-	case i == 16:
-		return _ObjectType_name_3
-	case i == 32:
-		return _ObjectType_name_4
-	case i == 64:
-		return _ObjectType_name_5
-	case i == 128:
-		return _ObjectType_name_6
-	case i == 256:
-		return _ObjectType_name_7
-	case i == 512:
-		return _ObjectType_name_8
-	default:
-		return "ObjectType(" + strconv.FormatInt(int64(i), 10) + ")"
+	if str, ok := _ObjectType_map[i]; ok {
+		return str
 	}
+	return "ObjectType(" + strconv.FormatInt(int64(i), 10) + ")"
 }
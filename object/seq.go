@@ -0,0 +1,67 @@
+package object
+
+// Iterator yields the elements of a Seq one at a time; ok is false once there
+// are no more elements.
+type Iterator interface {
+	Next() (elm Object, ok bool)
+}
+
+// IteratorFunc adapts a plain function to Iterator.
+type IteratorFunc func() (Object, bool)
+
+func (f IteratorFunc) Next() (Object, bool) { return f() }
+
+// Seq is a lazy sequence. Elements are only computed while iterating and
+// every call to Iter starts over, so the same Seq can be consumed many times.
+type Seq struct {
+	iter func() Iterator
+	desc string
+}
+
+func NewSeq(iter func() Iterator) *Seq {
+	return &Seq{iter: iter}
+}
+
+// NewRange returns the numbers from start to end (included or not), step
+// apart. A range going the opposite way of step is empty.
+func NewRange(start, end, step float64, inclusive bool) *Seq {
+	op := "..<"
+	if inclusive {
+		op = ".."
+	}
+	desc := FormatNumber(start, -1) + op + FormatNumber(end, -1)
+	if step != 1 {
+		desc += " step " + FormatNumber(step, -1)
+	}
+
+	return &Seq{
+		desc: desc,
+		iter: func() Iterator {
+			i := 0
+			return IteratorFunc(func() (Object, bool) {
+				// Multiplying instead of accumulating keeps float steps from
+				// drifting
+				v := start + float64(i)*step
+				switch {
+				case step > 0 && (v > end || (v == end && !inclusive)):
+					return nil, false
+				case step < 0 && (v < end || (v == end && !inclusive)):
+					return nil, false
+				}
+				i++
+				return NewNumber(v), true
+			})
+		},
+	}
+}
+
+func (s *Seq) Type() ObjectType { return TypeSeq }
+
+func (s *Seq) Iter() Iterator { return s.iter() }
+
+func (s *Seq) String() string {
+	if s.desc != "" {
+		return s.desc
+	}
+	return "<seq>"
+}
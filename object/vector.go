@@ -0,0 +1,126 @@
+package object
+
+// vector is a persistent vector: a 32-way trie whose last (partial) leaf is
+// kept apart as the tail, the same layout Clojure uses. Updates copy only the
+// path from the root to the touched leaf, so every version shares most of its
+// structure with the previous ones and push/assoc cost O(log32 n).
+type vector struct {
+	count int
+	shift uint
+	root  *vnode
+	tail  []Object
+}
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+// vnode is either a branch (nodes) or a leaf (values), never both.
+type vnode struct {
+	nodes  []*vnode
+	values []Object
+}
+
+var emptyVector = vector{shift: vecBits, root: &vnode{}}
+
+func (v vector) tailOffset() int {
+	if v.count < vecWidth {
+		return 0
+	}
+	return ((v.count - 1) >> vecBits) << vecBits
+}
+
+func (v vector) get(i int) Object {
+	if i >= v.tailOffset() {
+		return v.tail[i&vecMask]
+	}
+
+	node := v.root
+	for level := v.shift; level > 0; level -= vecBits {
+		node = node.nodes[(i>>level)&vecMask]
+	}
+	return node.values[i&vecMask]
+}
+
+func (v vector) push(obj Object) vector {
+	// Room left in the tail
+	if v.count-v.tailOffset() < vecWidth {
+		tail := make([]Object, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = obj
+		return vector{count: v.count + 1, shift: v.shift, root: v.root, tail: tail}
+	}
+
+	// Full tail, move it into the trie
+	leaf := &vnode{values: v.tail}
+	root, shift := v.root, v.shift
+	if (v.count >> vecBits) > (1 << v.shift) {
+		// The root itself is full, grow one level
+		root = &vnode{nodes: []*vnode{v.root, newPath(v.shift, leaf)}}
+		shift += vecBits
+	} else {
+		root = v.pushLeaf(v.shift, v.root, leaf)
+	}
+
+	return vector{count: v.count + 1, shift: shift, root: root, tail: []Object{obj}}
+}
+
+func (v vector) pushLeaf(level uint, parent, leaf *vnode) *vnode {
+	sub := ((v.count - 1) >> level) & vecMask
+
+	node := &vnode{nodes: make([]*vnode, len(parent.nodes), sub+1)}
+	copy(node.nodes, parent.nodes)
+
+	var child *vnode
+	switch {
+	case level == vecBits:
+		child = leaf
+	case sub < len(parent.nodes):
+		child = v.pushLeaf(level-vecBits, parent.nodes[sub], leaf)
+	default:
+		child = newPath(level-vecBits, leaf)
+	}
+
+	if sub < len(node.nodes) {
+		node.nodes[sub] = child
+	} else {
+		node.nodes = append(node.nodes, child)
+	}
+	return node
+}
+
+func newPath(level uint, leaf *vnode) *vnode {
+	if level == 0 {
+		return leaf
+	}
+	return &vnode{nodes: []*vnode{newPath(level-vecBits, leaf)}}
+}
+
+func (v vector) assoc(i int, obj Object) vector {
+	if i >= v.tailOffset() {
+		tail := make([]Object, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vecMask] = obj
+		return vector{count: v.count, shift: v.shift, root: v.root, tail: tail}
+	}
+
+	root := assocPath(v.shift, v.root, i, obj)
+	return vector{count: v.count, shift: v.shift, root: root, tail: v.tail}
+}
+
+func assocPath(level uint, node *vnode, i int, obj Object) *vnode {
+	if level == 0 {
+		values := make([]Object, len(node.values))
+		copy(values, node.values)
+		values[i&vecMask] = obj
+		return &vnode{values: values}
+	}
+
+	nodes := make([]*vnode, len(node.nodes))
+	copy(nodes, node.nodes)
+	sub := (i >> level) & vecMask
+	nodes[sub] = assocPath(level-vecBits, node.nodes[sub], i, obj)
+	return &vnode{nodes: nodes}
+}
@@ -17,6 +17,7 @@ const (
 	Logical    // && ||
 	Equality   // == !=
 	Relational // > >= < <=
+	Range      // .. ..<
 	Sum        // + -
 	Product    // * /
 	Prefix     // -x !x
@@ -25,29 +26,48 @@ const (
 )
 
 var precedences = map[token.TokenType]byte{
-	token.Pipe:     Pipe,
-	token.And:      Logical,
-	token.Or:       Logical,
-	token.Eq:       Equality,
-	token.Neq:      Equality,
-	token.Gt:       Relational,
-	token.Ge:       Relational,
-	token.Lt:       Relational,
-	token.Le:       Relational,
-	token.Plus:     Sum,
-	token.Minus:    Sum,
-	token.Mul:      Product,
-	token.Div:      Product,
-	token.LParen:   Call,
-	token.LBracket: Index,
+	token.Pipe:      Pipe,
+	token.And:       Logical,
+	token.Or:        Logical,
+	token.Eq:        Equality,
+	token.Neq:       Equality,
+	token.Gt:        Relational,
+	token.Ge:        Relational,
+	token.Lt:        Relational,
+	token.Le:        Relational,
+	token.Range:     Range,
+	token.RangeExcl: Range,
+	token.Plus:      Sum,
+	token.Minus:     Sum,
+	token.Mul:       Product,
+	token.Div:       Product,
+	token.LParen:    Call,
+	token.LBracket:  Index,
 }
 
 type prefixParseFn func() ast.Expression
 type infixParseFn func(ast.Expression) ast.Expression
 
-type parseErrors []error
+// Error is a parse error at some position of the input.
+type Error struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at line %d, column %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Errors are all errors found in the input. They make an error of their own,
+// listing them one per line.
+type Errors []error
 
-func (self parseErrors) String() string {
+func (self Errors) Error() string {
+	return self.String()
+}
+
+func (self Errors) String() string {
 	lines := []string{}
 	for _, e := range self {
 		lines = append(lines, "- "+e.Error())
@@ -61,7 +81,7 @@ type Parser struct {
 	curr token.Token
 	next token.Token
 
-	errors parseErrors
+	errors Errors
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -70,7 +90,7 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:              l,
-		errors:         parseErrors{},
+		errors:         Errors{},
 		prefixParseFns: map[token.TokenType]prefixParseFn{},
 		infixParseFns:  map[token.TokenType]infixParseFn{},
 	}
@@ -82,6 +102,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns[token.String] = p.parseString
 	p.prefixParseFns[token.LBracket] = p.parseArray
 	p.prefixParseFns[token.LBrace] = p.parseHash
+	p.prefixParseFns[token.LSet] = p.parseSet
 	p.prefixParseFns[token.True] = p.parseBool
 	p.prefixParseFns[token.False] = p.parseBool
 	p.prefixParseFns[token.Not] = p.parsePrefixExpression
@@ -103,13 +124,15 @@ func New(l *lexer.Lexer) *Parser {
 	p.infixParseFns[token.And] = p.parseInfixExpression
 	p.infixParseFns[token.Or] = p.parseInfixExpression
 	p.infixParseFns[token.Pipe] = p.parseInfixExpression
+	p.infixParseFns[token.Range] = p.parseRangeExpression
+	p.infixParseFns[token.RangeExcl] = p.parseRangeExpression
 	p.infixParseFns[token.LParen] = p.parseCallExpression
 	p.infixParseFns[token.LBracket] = p.parseIndexExpression
 
 	return p
 }
 
-func (p *Parser) Parse() (*ast.Module, parseErrors) {
+func (p *Parser) Parse() (*ast.Module, Errors) {
 	m := &ast.Module{Statements: []ast.Statement{}}
 
 	for p.curr.Type != token.EOF {
@@ -199,7 +222,7 @@ func (p *Parser) parseExpression(precedence byte) ast.Expression {
 }
 
 func (p *Parser) parseId() ast.Expression {
-	return &ast.Id{p.curr, p.curr.Literal}
+	return &ast.Id{Token: p.curr, Value: p.curr.Literal}
 }
 
 func (p *Parser) parseNumber() ast.Expression {
@@ -208,15 +231,15 @@ func (p *Parser) parseNumber() ast.Expression {
 		p.addError("could not parse %q as number", p.curr.Literal)
 		return nil
 	}
-	return &ast.Number{p.curr, v}
+	return &ast.Number{Token: p.curr, Value: v}
 }
 
 func (p *Parser) parseString() ast.Expression {
-	return &ast.String{p.curr, p.curr.Literal}
+	return &ast.String{Token: p.curr, Value: p.curr.Literal}
 }
 
 func (p *Parser) parseBool() ast.Expression {
-	return &ast.Bool{p.curr, p.curr.Type == token.True}
+	return &ast.Bool{Token: p.curr, Value: p.curr.Type == token.True}
 }
 
 func (p *Parser) parseArray() ast.Expression {
@@ -225,9 +248,15 @@ func (p *Parser) parseArray() ast.Expression {
 	return ary
 }
 
+func (p *Parser) parseSet() ast.Expression {
+	set := &ast.Set{Token: p.curr}
+	set.Elements = p.parseExpressionList(token.RBrace)
+	return set
+}
+
 func (p *Parser) parseHash() ast.Expression {
 	hash := &ast.Hash{Token: p.curr}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashPair{}
 
 	for p.next.Type != token.RBrace {
 		p.nextToken()
@@ -238,7 +267,8 @@ func (p *Parser) parseHash() ast.Expression {
 		}
 		p.nextToken()
 
-		hash.Pairs[key] = p.parseExpression(Lowest)
+		value := p.parseExpression(Lowest)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		if p.next.Type != token.RBrace && !p.assertNextIs(token.Comma) {
 			return nil
@@ -278,15 +308,67 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return e
 }
 
+func (p *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
+	e := &ast.Range{
+		Token:     p.curr,
+		Start:     left,
+		Inclusive: p.curr.Type == token.Range,
+	}
+
+	p.nextToken()
+	e.End = p.parseExpression(Range)
+
+	// step is not a keyword, only meaningful right after a range
+	if p.next.Type == token.Id && p.next.Literal == "step" {
+		p.nextToken()
+		p.nextToken()
+		e.Step = p.parseExpression(Range)
+	}
+
+	return e
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	tok := p.curr
+
+	// empty tuple
+	if p.next.Type == token.RParen {
+		p.nextToken()
+		return &ast.Tuple{Token: tok, Elements: []ast.Expression{}}
+	}
+
 	p.nextToken()
 	e := p.parseExpression(Lowest)
+	if p.next.Type == token.Comma {
+		return p.parseTuple(tok, e)
+	}
 	if !p.assertNextIs(token.RParen) {
 		return nil
 	}
 	return e
 }
 
+func (p *Parser) parseTuple(tok token.Token, first ast.Expression) ast.Expression {
+	t := &ast.Tuple{Token: tok, Elements: []ast.Expression{first}}
+
+	for p.next.Type == token.Comma {
+		p.nextToken()
+
+		// trailing comma, as in (x,)
+		if p.next.Type == token.RParen {
+			break
+		}
+		p.nextToken()
+		t.Elements = append(t.Elements, p.parseExpression(Lowest))
+	}
+
+	if !p.assertNextIs(token.RParen) {
+		return nil
+	}
+
+	return t
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	e := &ast.IfExpression{Token: p.curr}
 
@@ -417,10 +499,37 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	e := &ast.Index{Token: p.curr, Left: left}
 
+	// a[:b]
+	if p.next.Type == token.Colon {
+		return p.parseSliceExpression(e.Token, left, nil)
+	}
+
 	p.nextToken()
 
 	e.Index = p.parseExpression(Lowest)
 
+	// a[b:] or a[b:c]
+	if p.next.Type == token.Colon {
+		return p.parseSliceExpression(e.Token, left, e.Index)
+	}
+
+	if !p.assertNextIs(token.RBracket) {
+		return nil
+	}
+
+	return e
+}
+
+func (p *Parser) parseSliceExpression(tok token.Token, left, low ast.Expression) ast.Expression {
+	e := &ast.Slice{Token: tok, Left: left, Low: low}
+
+	p.nextToken() // :
+
+	if p.next.Type != token.RBracket {
+		p.nextToken()
+		e.High = p.parseExpression(Lowest)
+	}
+
 	if !p.assertNextIs(token.RBracket) {
 		return nil
 	}
@@ -457,6 +566,6 @@ func (p *Parser) nextPrecedence() byte {
 }
 
 func (p *Parser) addError(msg string, args ...interface{}) {
-	err := fmt.Errorf("at line %d, column %d: %s", p.curr.Line, p.curr.Col, fmt.Sprintf(msg, args...))
+	err := &Error{Line: p.curr.Line, Col: p.curr.Col, Msg: fmt.Sprintf(msg, args...)}
 	p.errors = append(p.errors, err)
 }
@@ -35,6 +35,30 @@ let foobar = y;
 	}
 }
 
+func TestErrors(t *testing.T) {
+	p := New(lexer.New(strings.NewReader("let = 1\nlet x 2")))
+	_, errs := p.Parse()
+
+	expected := []Error{
+		{1, 4, "expected next token to be Id, got Assign instead"},
+		{1, 6, "no prefix func for Assign"},
+		{2, 6, "expected next token to be Assign, got Number instead"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("parse should produce %d errors; got %v", len(expected), errs)
+	}
+	for i, e := range expected {
+		if actual := *errs[i].(*Error); actual != e {
+			t.Errorf("error should be %+v; got %+v", e, actual)
+		}
+	}
+
+	msg := "\n- at line 1, column 4: expected next token to be Id, got Assign instead\n"
+	if !strings.HasPrefix(errs.Error(), msg) {
+		t.Errorf("errors should print one per line; got %q", errs.Error())
+	}
+}
+
 func TestReturn(t *testing.T) {
 	tt := []struct {
 		input string
@@ -154,26 +178,26 @@ func TestExpressions(t *testing.T) {
 				case map[string]float64:
 
 					i := 1
-					for k, v := range hash.Pairs {
+					for _, pair := range hash.Pairs {
 						t.Run(fmt.Sprintf("testing %d", i), func(t *testing.T) {
-							key, ok := k.(*ast.String)
+							key, ok := pair.Key.(*ast.String)
 							if !ok {
-								t.Errorf("key should be *ast.String; got %T", k)
+								t.Errorf("key should be *ast.String; got %T", pair.Key)
 							}
-							testNumberLiteral(t, v, val[key.Value])
+							testNumberLiteral(t, pair.Value, val[key.Value])
 						})
 						i++
 					}
 				case map[string]func(ast.Expression):
 
 					i := 1
-					for k, v := range hash.Pairs {
+					for _, pair := range hash.Pairs {
 						t.Run(fmt.Sprintf("testing %d", i), func(t *testing.T) {
-							key, ok := k.(*ast.String)
+							key, ok := pair.Key.(*ast.String)
 							if !ok {
-								t.Errorf("key should be *ast.String; got %T", k)
+								t.Errorf("key should be *ast.String; got %T", pair.Key)
 							}
-							val[key.Value](v)
+							val[key.Value](pair.Value)
 						})
 						i++
 					}
@@ -457,6 +481,26 @@ func TestOperatorPrecedence(t *testing.T) {
 			{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))", 1},
 			{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)", 1},
 			{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))", 1},
+			{`{"c": 1, "a": 2 * 3, "b": 3}`, "{c: 1, a: (2 * 3), b: 3}", 1},
+			{"#{}", "#{}", 1},
+			{"#{1, 2 * 3}", "#{1, (2 * 3)}", 1},
+			{"()", "()", 1},
+			{"(1)", "1", 1},
+			{"(1,)", "(1,)", 1},
+			{"(1, 2 + 3)", "(1, (2 + 3))", 1},
+			{"(a, b,)[0]", "((a, b)[0])", 1},
+			{"a[1:2]", "(a[1:2])", 1},
+			{"a[:n + 1]", "(a[:(n + 1)])", 1},
+			{"a[-2:]", "(a[(-2):])", 1},
+			{"a[:]", "(a[:])", 1},
+			{"a[1:][0]", "((a[1:])[0])", 1},
+			{"1..10", "(1..10)", 1},
+			{"0..<n + 1", "(0..<(n + 1))", 1},
+			{"1.5..3", "(1.5..3)", 1},
+			{"1..10 step 2", "(1..10 step 2)", 1},
+			{"10..1 step -1 | map(f)", "((10..1 step (-1)) | map(f))", 1},
+			{"a..b == c", "((a..b) == c)", 1},
+			{"a * b[1:2] + c", "((a * (b[1:2])) + c)", 1},
 		}
 
 		for _, tc := range tt {
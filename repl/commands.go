@@ -0,0 +1,182 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geovanisouza92/geo/eval"
+	"github.com/geovanisouza92/geo/lexer"
+	"github.com/geovanisouza92/geo/object"
+	"github.com/geovanisouza92/geo/token"
+)
+
+type command struct {
+	args string
+	help string
+	run  func(s *session, arg string) bool // true to leave the REPL
+}
+
+var commands map[string]command
+
+// Set in init, as :help lists the commands
+func init() {
+	commands = map[string]command{
+		"load":   {"file.geo", "evaluate a script into the session", (*session).load},
+		"type":   {"expr", "print the type of expr", (*session).typeOf},
+		"ast":    {"expr", "print how expr parses", (*session).ast},
+		"tokens": {"expr", "print the tokens of expr", (*session).tokens},
+		"env":    {"", "list the names defined in the session", (*session).env},
+		"reset":  {"", "forget the names defined in the session", (*session).reset},
+		"doc":    {"name", "describe a builtin or function", (*session).doc},
+		"time":   {"expr", "evaluate expr and tell how long it took", (*session).time},
+		"help":   {"", "list the commands", (*session).help},
+	}
+}
+
+// command runs the command in line, as in `load file.geo`, and tells whether
+// to leave the REPL.
+func (s *session) command(line string) bool {
+	line = strings.TrimSpace(line)
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command :%s; try :help\n", name)
+		return false
+	}
+	if cmd.args != "" && arg == "" {
+		fmt.Fprintf(s.out, "usage: :%s %s\n", name, cmd.args)
+		return false
+	}
+	return cmd.run(s, arg)
+}
+
+func (s *session) load(path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return false
+	}
+	return s.eval(string(b), s.print)
+}
+
+// eval runs input and hands its value to done, unless it calls exit.
+func (s *session) eval(input string, done func(object.Object)) bool {
+	e, err := s.run(input)
+	if err != nil {
+		io.WriteString(s.out, err.Error())
+		return false
+	}
+	if code, ok := eval.ExitCode(e); ok {
+		s.code = code
+		return true
+	}
+	done(e)
+	return false
+}
+
+func (s *session) typeOf(expr string) bool {
+	return s.eval(expr, func(e object.Object) {
+		if e != nil {
+			fmt.Fprintln(s.out, e.Type())
+		}
+	})
+}
+
+func (s *session) ast(expr string) bool {
+	m, err := eval.Compile(expr)
+	if err != nil {
+		io.WriteString(s.out, err.Error())
+		return false
+	}
+	fmt.Fprintln(s.out, m.String())
+	return false
+}
+
+func (s *session) tokens(expr string) bool {
+	l := lexer.New(strings.NewReader(expr))
+	for t := l.NextToken(); t.Type != token.EOF; t = l.NextToken() {
+		fmt.Fprintf(s.out, "%d:%d\t%s\t%q\n", t.Line, t.StartCol(), t.Type, t.Literal)
+	}
+	return false
+}
+
+func (s *session) env(string) bool {
+	for _, name := range s.scope.Names() {
+		v, _ := s.scope.Get(name)
+		fmt.Fprintf(s.out, "%s = %s : %s\n", name, s.c.Format(v), v.Type())
+	}
+	return false
+}
+
+func (s *session) reset(string) bool {
+	s.start()
+	return false
+}
+
+// doc tells how to call a builtin and what it does, or shows the source of a
+// function.
+func (s *session) doc(name string) bool {
+	if v, ok := s.scope.Get(name); ok {
+		fmt.Fprintf(s.out, "%s = %s : %s\n", name, v.String(), v.Type())
+		return false
+	}
+	b, ok := s.c.Builtin(name)
+	if !ok {
+		fmt.Fprintf(s.out, "no builtin or name %s\n", name)
+		return false
+	}
+	fmt.Fprintln(s.out, signature(b))
+	if b.Doc != "" {
+		fmt.Fprintln(s.out, b.Doc)
+	}
+	return false
+}
+
+// signature describes the parameters of b by their types, as in
+// `split(TypeString, TypeString)`.
+func signature(b *object.Builtin) string {
+	if b.Params == nil {
+		return b.Name + "(...)"
+	}
+
+	params := make([]string, len(b.Params))
+	for i, p := range b.Params {
+		if p == object.TypeAny {
+			params[i] = "any"
+		} else {
+			params[i] = strings.Replace(object.ObjectTypesToString(p), ", ", " | ", -1)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", b.Name, strings.Join(params, ", "))
+}
+
+func (s *session) time(expr string) bool {
+	start := time.Now()
+	return s.eval(expr, func(e object.Object) {
+		elapsed := time.Since(start)
+		s.print(e)
+		fmt.Fprintf(s.out, "took %s\n", elapsed)
+	})
+}
+
+func (s *session) help(string) bool {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(s.out, "  %-20s %s\n", strings.TrimSpace(":"+name+" "+cmd.args), cmd.help)
+	}
+	return false
+}
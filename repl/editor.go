@@ -0,0 +1,483 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+// maxHistory is how many lines are kept from the history file
+const maxHistory = 1000
+
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads the input a line at a time, after showing the prompt.
+// Complete inputs, which may take several lines, are handed to remember.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+	remember(input string)
+}
+
+// scanReader reads lines as they come, for input that isn't a terminal.
+type scanReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *scanReader) readLine(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+func (r *scanReader) remember(string) {}
+
+// editor reads lines from a terminal, with Emacs keys to edit them:
+//
+//	C-a, C-e       go to the start, end of the line
+//	C-b, C-f       go back, forward a character
+//	M-b, M-f       go back, forward a word
+//	C-h, C-d       delete the character before, under the cursor
+//	C-k, C-u       cut to the end, start of the line
+//	C-w, M-d       cut the word before, after the cursor
+//	C-y            paste what was cut last
+//	C-p, C-n       go to the previous, next line in the history
+//	C-l            clear the screen
+//	C-c            drop the input
+//	Tab            complete the name before the cursor
+//
+// The arrow, Home, End and Delete keys work as expected too. Inputs taking
+// several lines are kept in the history as one entry, and are edited as one
+// when brought back.
+type editor struct {
+	in  *bufio.Reader
+	out io.Writer
+	fd  int // of the terminal, to put it in raw mode, or -1
+
+	history []string
+	path    string // where history is kept, or "" to not keep it
+
+	complete func() []string // names to complete
+
+	prompt string
+	line   []rune
+	pos    int
+	row    int // of the cursor, from the first line drawn
+	kill   []rune
+	hist   int    // position in history, or len(history) for the new line
+	draft  string // the new line, while going through history
+}
+
+func newEditor(in *bufio.Reader, fd int, out io.Writer, path string, complete func() []string) *editor {
+	return &editor{
+		in:       in,
+		out:      out,
+		fd:       fd,
+		history:  loadHistory(path),
+		path:     path,
+		complete: complete,
+	}
+}
+
+// historyPath is where the history is kept, in the user configuration
+// directory, or "" when there is none.
+func historyPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "geo", "history")
+}
+
+// historyEscaper and historyUnescaper keep each entry of the history file in a
+// line of its own, escaping the line breaks of inputs taking several lines.
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+	}
+	for i, line := range lines {
+		lines[i] = historyUnescaper.Replace(line)
+	}
+	return lines
+}
+
+// remember adds input to the history, and to its file. Failing to write the
+// file only loses history, so errors are ignored.
+func (e *editor) remember(input string) {
+	if strings.TrimSpace(input) == "" {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == input {
+		return
+	}
+	e.history = append(e.history, input)
+
+	if e.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(e.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, historyEscaper.Replace(input))
+	f.Close()
+}
+
+func ctrl(r rune) rune {
+	return r & 0x1f
+}
+
+func (e *editor) readLine(prompt string) (string, error) {
+	if e.fd >= 0 {
+		state, err := term.MakeRaw(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer term.Restore(e.fd, state)
+	}
+
+	e.prompt, e.line, e.pos, e.row = prompt, nil, 0, 0
+	e.hist, e.draft = len(e.history), ""
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			e.pos = len(e.line)
+			e.refresh()
+			io.WriteString(e.out, "\r\n")
+			return string(e.line), nil
+		case ctrl('c'):
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('d'):
+			if len(e.line) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.cut(e.pos, e.next(), false)
+		case ctrl('h'), 127:
+			e.cut(e.prev(), e.pos, false)
+		case ctrl('a'):
+			e.pos = 0
+		case ctrl('e'):
+			e.pos = len(e.line)
+		case ctrl('b'):
+			e.pos = e.prev()
+		case ctrl('f'):
+			e.pos = e.next()
+		case ctrl('k'):
+			e.cut(e.pos, len(e.line), true)
+		case ctrl('u'):
+			e.cut(0, e.pos, true)
+		case ctrl('w'):
+			e.cut(e.wordBack(), e.pos, true)
+		case ctrl('y'):
+			e.insert(e.kill...)
+		case ctrl('p'):
+			e.walkHistory(-1)
+		case ctrl('n'):
+			e.walkHistory(1)
+		case ctrl('l'):
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+		case '\t':
+			e.completeWord()
+		case 27:
+			// Terminals send the escape sequences of keys all at once, so
+			// an ESC with nothing after it yet is a key of its own, which
+			// does nothing, rather than the start of a sequence to wait for
+			if e.in.Buffered() > 0 {
+				e.escape()
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(r)
+			}
+		}
+
+		if e.pos < 0 {
+			e.pos = 0
+		}
+		if e.pos > len(e.line) {
+			e.pos = len(e.line)
+		}
+		e.refresh()
+	}
+}
+
+// escape handles the keys sent as escape sequences: Meta with a letter, like
+// M-b, and the arrows and such, like ESC [ A.
+func (e *editor) escape() {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+
+	switch r {
+	case 'b':
+		e.pos = e.wordBack()
+		return
+	case 'f':
+		e.pos = e.wordForward()
+		return
+	case 'd':
+		e.cut(e.pos, e.wordForward(), true)
+		return
+	case '[', 'O':
+	default:
+		return
+	}
+
+	// Read up to the final letter or ~, or as far as was sent
+	var seq []rune
+	for e.in.Buffered() > 0 {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return
+		}
+		seq = append(seq, r)
+		if unicode.IsLetter(r) || r == '~' {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		e.walkHistory(-1)
+	case "B":
+		e.walkHistory(1)
+	case "C":
+		e.pos = e.next()
+	case "D":
+		e.pos = e.prev()
+	case "H", "1~", "7~":
+		e.pos = 0
+	case "F", "4~", "8~":
+		e.pos = len(e.line)
+	case "3~":
+		e.cut(e.pos, e.next(), false)
+	}
+}
+
+// prev and next are the positions of the characters before and after the
+// cursor. Combining marks go with the character they follow.
+func (e *editor) prev() int {
+	i := e.pos - 1
+	for i > 0 && runeWidth(e.line[i]) == 0 {
+		i--
+	}
+	return i
+}
+
+func (e *editor) next() int {
+	i := e.pos + 1
+	for i < len(e.line) && runeWidth(e.line[i]) == 0 {
+		i++
+	}
+	return i
+}
+
+// runeWidth is how many columns r takes in a terminal: none for combining
+// marks and other invisible characters, two for wide East Asian ones.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// stringWidth is how many columns s takes in a terminal.
+func stringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+func (e *editor) insert(rs ...rune) {
+	line := make([]rune, 0, len(e.line)+len(rs))
+	line = append(line, e.line[:e.pos]...)
+	line = append(line, rs...)
+	e.line = append(line, e.line[e.pos:]...)
+	e.pos += len(rs)
+}
+
+// cut removes the characters between from and to, keeping them to paste back
+// when kill is set.
+func (e *editor) cut(from, to int, kill bool) {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(e.line) {
+		to = len(e.line)
+	}
+	if from >= to {
+		return
+	}
+	if kill {
+		e.kill = append([]rune(nil), e.line[from:to]...)
+	}
+	e.line = append(e.line[:from:from], e.line[to:]...)
+	e.pos = from
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '?' || r == '!'
+}
+
+func (e *editor) wordBack() int {
+	i := e.pos
+	for i > 0 && !isWordRune(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+func (e *editor) wordForward() int {
+	i := e.pos
+	for i < len(e.line) && !isWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && isWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+// walkHistory goes by steps through the history, keeping the new line to come
+// back to it.
+func (e *editor) walkHistory(by int) {
+	i := e.hist + by
+	if i < 0 || i > len(e.history) {
+		return
+	}
+	if e.hist == len(e.history) {
+		e.draft = string(e.line)
+	}
+
+	e.hist = i
+	if i == len(e.history) {
+		e.line = []rune(e.draft)
+	} else {
+		e.line = []rune(e.history[i])
+	}
+	e.pos = len(e.line)
+}
+
+// completeWord completes the name before the cursor as far as all the names
+// it could be agree. When they don't, it lists them.
+func (e *editor) completeWord() {
+	start := e.pos
+	for start > 0 && isWordRune(e.line[start-1]) {
+		start--
+	}
+	prefix := string(e.line[start:e.pos])
+	if prefix == "" {
+		return
+	}
+
+	var matches []string
+	for _, name := range e.complete() {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		io.WriteString(e.out, "\a")
+		return
+	}
+
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	if len(common) > len(prefix) {
+		e.insert([]rune(common[len(prefix):])...)
+		return
+	}
+	io.WriteString(e.out, "\r\n"+strings.Join(matches, "  ")+"\r\n")
+}
+
+// refresh draws the line again and puts the cursor back in place. Inputs from
+// the history may take several lines, drawn after ContPrompt.
+func (e *editor) refresh() {
+	var b strings.Builder
+	if e.row > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", e.row)
+	}
+	b.WriteString("\r\x1b[J")
+
+	lines := strings.Split(string(e.line), "\n")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(e.promptAt(i) + line)
+	}
+
+	// Go back up to the line of the cursor, and across to its column
+	before := strings.Split(string(e.line[:e.pos]), "\n")
+	e.row = len(before) - 1
+	if up := len(lines) - 1 - e.row; up > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", up)
+	}
+	b.WriteString("\r")
+	if col := stringWidth(e.promptAt(e.row) + before[e.row]); col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+	io.WriteString(e.out, b.String())
+}
+
+func (e *editor) promptAt(row int) string {
+	if row == 0 {
+		return e.prompt
+	}
+	return ContPrompt
+}
@@ -0,0 +1,158 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditor(t *testing.T) {
+	names := func() []string { return []string{"filter", "find", "let", "map"} }
+
+	tt := []struct {
+		keys     string
+		expected string
+	}{
+		{"ab\x02c\r", "acb"},
+		{"abc\x01x\x05y\r", "xabcy"},
+		{"abc\x1b[D\x1b[D\x7f\r", "bc"},
+		{"abc\x1b[D\x04\r", "ab"},
+		{"foo bar\x17baz\r", "foo baz"},
+		{"foo bar\x1bb\x0b\x01\x19\r", "barfoo "},
+		{"foo bar\x1bb\x1bb\x1bd\r", " bar"},
+		{"x = ma\t\r", "x = map"},
+		{"fi\t\r", "fi"},
+		{"fil\t(\r", "filter("},
+		{"zz\t\r", "zz"},
+		{"1 \x1b[H\x1b[3~\x1b[F2\r", " 2"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.expected, func(t *testing.T) {
+			e := newEditor(bufio.NewReader(strings.NewReader(tc.keys)), -1, ioutil.Discard, "", names)
+			line, err := e.readLine(Prompt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != tc.expected {
+				t.Errorf("line should be %q; got %q", tc.expected, line)
+			}
+		})
+	}
+}
+
+func TestEditorInterrupt(t *testing.T) {
+	e := newEditor(bufio.NewReader(strings.NewReader("abc\x03\x04")), -1, ioutil.Discard, "", nil)
+
+	if _, err := e.readLine(Prompt); err != errInterrupted {
+		t.Errorf("C-c should interrupt; got %v", err)
+	}
+	if _, err := e.readLine(Prompt); err != io.EOF {
+		t.Errorf("C-d on an empty line should end the input; got %v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo", "history")
+	keys := "let x = 1\r" + "x + 1\r" + "x + 1\r" + "\x10\x10\x10\x0e\r" + "new\x1b[A\x1b[B\r" +
+		"let f = fn() {\r1\r}\r" + "\x10\x02\x02\x7f2\r" + "\"\\n\"\r"
+
+	e := newEditor(bufio.NewReader(strings.NewReader(keys)), -1, ioutil.Discard, path, nil)
+	var inputs []string
+	for {
+		input, ok := readInput(e)
+		if !ok {
+			break
+		}
+		inputs = append(inputs, input)
+	}
+
+	expected := []string{"let x = 1", "x + 1", "x + 1", "x + 1", "new", "let f = fn() {\n1\n}", "let f = fn() {\n2\n}", `"\n"`}
+	if strings.Join(inputs, "|") != strings.Join(expected, "|") {
+		t.Errorf("inputs should be %q; got %q", expected, inputs)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "let x = 1\nx + 1\nnew\nlet f = fn() {\\n1\\n}\nlet f = fn() {\\n2\\n}\n\"\\\\n\"\n" {
+		t.Errorf("history file should keep an input a line; got %q", b)
+	}
+
+	h := newEditor(bufio.NewReader(&bytes.Buffer{}), -1, ioutil.Discard, path, nil).history
+	remembered := []string{"let x = 1", "x + 1", "new", "let f = fn() {\n1\n}", "let f = fn() {\n2\n}", `"\n"`}
+	if strings.Join(h, "|") != strings.Join(remembered, "|") {
+		t.Errorf("history should be loaded from the file; got %q", h)
+	}
+}
+
+// chunkReader returns a chunk a read, as a terminal returns the keys typed
+// since the last read.
+type chunkReader []string
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(*r) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*r)[0])
+	*r = (*r)[1:]
+	return n, nil
+}
+
+func TestEditorEscape(t *testing.T) {
+	tt := []struct {
+		chunks   chunkReader
+		expected string
+	}{
+		{chunkReader{"ab", "\x1b", "c\r"}, "abc"},
+		{chunkReader{"ab", "\x1b[D", "c\r"}, "acb"},
+		{chunkReader{"ab", "\x1b[", "c\r"}, "abc"},
+		{chunkReader{"ab cd", "\x1bb", "x\r"}, "ab xcd"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.expected, func(t *testing.T) {
+			e := newEditor(bufio.NewReader(&tc.chunks), -1, ioutil.Discard, "", nil)
+			line, err := e.readLine(Prompt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != tc.expected {
+				t.Errorf("line should be %q; got %q", tc.expected, line)
+			}
+		})
+	}
+}
+
+func TestEditorWidth(t *testing.T) {
+	tt := []struct {
+		keys     string
+		expected string
+	}{
+		{"ab\x02", "\r\x1b[J>> ab\r\x1b[4C"},
+		{"日本\x02", "\r\x1b[J>> 日本\r\x1b[5C"},
+		{"ée\u0301\x02", "\r\x1b[J>> ée\u0301\r\x1b[4C"},
+		{"ae\u0301\x7f", "\r\x1b[J>> a\r\x1b[4C"},
+		{"\x10\x02", "\x1b[2A\r\x1b[J>> let f = fn() {\r\n..   1\r\n.. }\r\x1b[3C"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.keys, func(t *testing.T) {
+			var out bytes.Buffer
+			e := newEditor(bufio.NewReader(strings.NewReader(tc.keys)), -1, &out, "", nil)
+			e.history = []string{"let f = fn() {\n  1\n}"}
+			e.readLine(Prompt)
+
+			out.Reset()
+			e.refresh()
+			if out.String() != tc.expected {
+				t.Errorf("line should be drawn as %q; got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
@@ -4,47 +4,194 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/geovanisouza92/geo/eval"
+	"github.com/geovanisouza92/geo/lexer"
 	"github.com/geovanisouza92/geo/object"
+	"github.com/geovanisouza92/geo/parser"
+	"github.com/geovanisouza92/geo/token"
+	"golang.org/x/term"
 )
 
-const Prompt = ">> "
+const (
+	Prompt = ">> "
 
+	// ContPrompt asks for the rest of an incomplete input
+	ContPrompt = ".. "
+)
+
+// session is what lasts between inputs.
+type session struct {
+	scope *object.Scope
+	c     *eval.Context
+	in    *bufio.Reader
+	out   io.Writer
+	setup func(*eval.Context)
+	code  int // given to exit
+}
+
+// Options configure the REPL run by StartWith.
+type Options struct {
+	// Setup, when not nil, configures the context inputs run in, as to grant
+	// it capabilities.
+	Setup func(*eval.Context)
+}
+
+// Start runs the REPL. When in is a terminal, lines can be edited, and are
+// kept in a history file.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	scope := object.NewRootScope()
+	StartWith(in, out, Options{})
+}
+
+// StartWith runs the REPL like Start, configured by opts. It returns the
+// status an input gave to exit, or 0 when in ends.
+func StartWith(in io.Reader, out io.Writer, opts Options) int {
+	// The REPL and the scripts read the same input, so they share its buffer
+	s := &session{in: bufio.NewReader(in), out: out, setup: opts.Setup}
+	s.start()
+
+	var r lineReader = &scanReader{in: s.in, out: out}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		r = newEditor(s.in, int(f.Fd()), out, historyPath(), s.names)
+	}
 
 	for {
-		fmt.Print(Prompt)
 		// Read
-		if !scanner.Scan() {
-			return // EOF
+		input, ok := readInput(r)
+		if !ok {
+			return 0 // EOF
+		}
+
+		if strings.HasPrefix(input, ":") {
+			if s.command(input[1:]) {
+				return s.code
+			}
+			continue
 		}
-		input := scanner.Text()
 
 		// Eval
-		e, err := run(input, scope)
+		e, err := s.run(input)
 		if err != nil {
 			io.WriteString(out, err.Error())
 			continue
 		}
 
-		// Print
-		if e != nil {
-			io.WriteString(out, fmt.Sprintf("%v : %s", e.String(), e.Type()))
-			io.WriteString(out, "\n")
+		if code, ok := eval.ExitCode(e); ok {
+			return code
 		}
 
+		// Print
+		s.print(e)
+
 		// Loop
 	}
 }
 
-func run(input string, scope *object.Scope) (object.Object, error) {
-	s, err := eval.Compile(input)
+func (s *session) print(e object.Object) {
+	if e != nil {
+		io.WriteString(s.out, fmt.Sprintf("%v : %s", s.c.Format(e), e.Type()))
+		io.WriteString(s.out, "\n")
+	}
+}
+
+// readInput reads lines until they make a complete input, which r remembers.
+// An empty line ends the input anyway, to get out of a mistake, and an
+// interrupt drops it.
+func readInput(r lineReader) (string, bool) {
+	input, err := r.readLine(Prompt)
+	if err == errInterrupted {
+		return "", true
+	}
+	if err != nil {
+		return "", false
+	}
+	if strings.HasPrefix(input, ":") {
+		r.remember(input)
+		return input, true // commands take one line
+	}
+
+	for incomplete(input) {
+		line, err := r.readLine(ContPrompt)
+		if err == errInterrupted {
+			return "", true
+		}
+		if err != nil || line == "" {
+			break
+		}
+		input += "\n" + line
+	}
+	r.remember(input)
+	return input, true
+}
+
+// incomplete tells whether input lacks its end: some bracket is left open, or
+// the parser ran out of tokens, as in `let x =`.
+func incomplete(input string) bool {
+	l := lexer.New(strings.NewReader(input))
+	depth := 0
+	var eof token.Token
+	for eof.Type != token.EOF {
+		t := l.NextToken()
+		switch t.Type {
+		case token.LParen, token.LBrace, token.LBracket, token.LSet:
+			depth++
+		case token.RParen, token.RBrace, token.RBracket:
+			depth--
+		case token.EOF:
+			eof = t
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+
+	_, err := eval.Compile(input)
+	errs, _ := err.(parser.Errors)
+	for _, err := range errs {
+		if pe, ok := err.(*parser.Error); ok && pe.Line == eof.Line && pe.Col == eof.Col {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *session) run(input string) (object.Object, error) {
+	m, err := eval.Compile(input)
 	if err != nil {
 		return nil, err
 	}
-	c := eval.NewContext(scope)
-	return c.Eval(s), nil
+	return s.c.Eval(m), nil
+}
+
+// names are the ones to complete: those in the session, the builtins,
+// constants and keywords.
+func (s *session) names() []string {
+	names := append(s.scope.Names(), s.c.Globals()...)
+	names = append(names, token.Keywords()...)
+	sort.Strings(names)
+
+	// Scripts may shadow builtins
+	uniq := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			uniq = append(uniq, name)
+		}
+	}
+	return uniq
+}
+
+// start gives the session a new scope, and a context for inputs to run in.
+// Scripts write their errors to out, where the REPL shows its own too.
+func (s *session) start() {
+	s.scope = object.NewRootScope()
+	s.c = eval.NewContext(s.scope)
+	s.c.SetStdin(s.in)
+	s.c.SetStdout(s.out)
+	s.c.SetStderr(s.out)
+	if s.setup != nil {
+		s.setup(s.c)
+	}
 }
@@ -0,0 +1,111 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	in := strings.NewReader("let x = 2\nputs!(x * 3)\nx + 1\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">> >> 6\nnull : TypeNull\n>> 3 : TypeNumber\n>> "
+	if out.String() != expected {
+		t.Errorf("output should be %q; got %q", expected, out.String())
+	}
+}
+
+func TestStartStdin(t *testing.T) {
+	in := strings.NewReader("let x = read_line()\nhello\nx\neputs!(x)\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">> >> hello : TypeString\n>> hello\nnull : TypeNull\n>> "
+	if out.String() != expected {
+		t.Errorf("output should be %q; got %q", expected, out.String())
+	}
+}
+
+func TestStartMultiline(t *testing.T) {
+	in := strings.NewReader("let double = fn(x) {\n  x * 2\n};\ndouble(\n2)\nlet y =\n\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">> .. .. >> .. 4 : TypeNumber\n>> .. \n- at line 1, column 8: no prefix func for EOF\n>> "
+	if out.String() != expected {
+		t.Errorf("output should be %q; got %q", expected, out.String())
+	}
+}
+
+func TestStartWithExit(t *testing.T) {
+	tt := []struct {
+		input string
+		code  int
+	}{
+		{"1\n", 0},
+		{"exit(3)\n2\n", 3},
+		{":time exit(4)\n", 4},
+		{":type exit(0)\n", 0},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			var out bytes.Buffer
+			if code := StartWith(strings.NewReader(tc.input), &out, Options{}); code != tc.code {
+				t.Errorf("exit status should be %d; got %d", tc.code, code)
+			}
+		})
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 1", false},
+		{"1 ++ 2", false},
+		{"let x =", true},
+		{"fn(x) {", true},
+		{"[1, 2", true},
+		{"if (x) { 1 } else", true},
+		{"#{1,\n2", true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			if incomplete(tc.input) != tc.expected {
+				t.Errorf("incomplete should be %v", tc.expected)
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	in := strings.NewReader("let x = 2\n:env\n:type x * 2\n:ast 1 + 2 * x\n:tokens x * \"ab\"\n:doc split\n:reset\n:env\n:bogus\n:type\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := strings.Join([]string{
+		">> >> x = 2 : TypeNumber",
+		">> TypeNumber",
+		">> (1 + (2 * x))",
+		">> 1:1\tId\t\"x\"",
+		"1:3\tMul\t\"*\"",
+		"1:5\tString\t\"ab\"",
+		">> split(TypeString, TypeString)",
+		"split(sep, s) are the parts of s between each sep; an empty sep splits s into characters.",
+		">> >> >> unknown command :bogus; try :help",
+		">> usage: :type expr",
+		">> ",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("output should be %q; got %q", expected, out.String())
+	}
+}
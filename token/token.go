@@ -1,5 +1,10 @@
 package token
 
+import (
+	"sort"
+	"unicode/utf8"
+)
+
 type Token struct {
 	Type    TokenType
 	Literal string
@@ -7,6 +12,13 @@ type Token struct {
 	Col     int
 }
 
+// StartCol returns the column where the token starts. Col is the column just
+// past the token; for quoted strings, whose Literal has no quotes, the lexer
+// sets Col so that StartCol is the column of the opening quote.
+func (t Token) StartCol() int {
+	return t.Col - utf8.RuneCountInString(t.Literal)
+}
+
 //go:generate stringer -type=TokenType
 
 type TokenType byte
@@ -21,21 +33,23 @@ const (
 	String
 
 	// Operators
-	Assign // =
-	Plus   // +
-	Minus  // -
-	Mul    // *
-	Div    // /
-	Not    // !
-	Eq     // ==
-	Neq    // !=
-	Gt     // >
-	Ge     // >=
-	Lt     // <
-	Le     // <=
-	Pipe   // |
-	And    // &&
-	Or     // ||
+	Assign    // =
+	Plus      // +
+	Minus     // -
+	Mul       // *
+	Div       // /
+	Not       // !
+	Eq        // ==
+	Neq       // !=
+	Gt        // >
+	Ge        // >=
+	Lt        // <
+	Le        // <=
+	Pipe      // |
+	And       // &&
+	Or        // ||
+	Range     // ..
+	RangeExcl // ..<
 
 	// Delimiters
 	EOL      // ;
@@ -47,6 +61,7 @@ const (
 	RBrace   // }
 	LBracket // [
 	RBracket // ]
+	LSet     // #{
 
 	// Keywords
 	Fn
@@ -68,6 +83,16 @@ var keywords = map[string]TokenType{
 	"else":   Else,
 }
 
+// Keywords returns the reserved words, sorted.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
 func LookupId(id string) TokenType {
 	if t, ok := keywords[id]; ok {
 		return t
@@ -1,16 +1,62 @@
-// Code generated by "stringer -type=TokenType"; DO NOT EDIT
+// Code generated by "stringer -type=TokenType"; DO NOT EDIT.
 
 package token
 
-import "fmt"
+import "strconv"
 
-const _TokenType_name = "ErrorEOFIdNumberStringAssignPlusMinusMulDivNotEqNeqGtGeLtLePipeAndOrEOLCommaColonLParenRParenLBraceRBraceLBracketRBracketFnLetReturnTrueFalseIfElse"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Error-0]
+	_ = x[EOF-1]
+	_ = x[Id-2]
+	_ = x[Number-3]
+	_ = x[String-4]
+	_ = x[Assign-5]
+	_ = x[Plus-6]
+	_ = x[Minus-7]
+	_ = x[Mul-8]
+	_ = x[Div-9]
+	_ = x[Not-10]
+	_ = x[Eq-11]
+	_ = x[Neq-12]
+	_ = x[Gt-13]
+	_ = x[Ge-14]
+	_ = x[Lt-15]
+	_ = x[Le-16]
+	_ = x[Pipe-17]
+	_ = x[And-18]
+	_ = x[Or-19]
+	_ = x[Range-20]
+	_ = x[RangeExcl-21]
+	_ = x[EOL-22]
+	_ = x[Comma-23]
+	_ = x[Colon-24]
+	_ = x[LParen-25]
+	_ = x[RParen-26]
+	_ = x[LBrace-27]
+	_ = x[RBrace-28]
+	_ = x[LBracket-29]
+	_ = x[RBracket-30]
+	_ = x[LSet-31]
+	_ = x[Fn-32]
+	_ = x[Let-33]
+	_ = x[Return-34]
+	_ = x[True-35]
+	_ = x[False-36]
+	_ = x[If-37]
+	_ = x[Else-38]
+}
+
+const _TokenType_name = "ErrorEOFIdNumberStringAssignPlusMinusMulDivNotEqNeqGtGeLtLePipeAndOrRangeRangeExclEOLCommaColonLParenRParenLBraceRBraceLBracketRBracketLSetFnLetReturnTrueFalseIfElse"
 
-var _TokenType_index = [...]uint8{0, 5, 8, 10, 16, 22, 28, 32, 37, 40, 43, 46, 48, 51, 53, 55, 57, 59, 63, 66, 68, 71, 76, 81, 87, 93, 99, 105, 113, 121, 123, 126, 132, 136, 141, 143, 147}
+var _TokenType_index = [...]uint8{0, 5, 8, 10, 16, 22, 28, 32, 37, 40, 43, 46, 48, 51, 53, 55, 57, 59, 63, 66, 68, 73, 82, 85, 90, 95, 101, 107, 113, 119, 127, 135, 139, 141, 144, 150, 154, 159, 161, 165}
 
 func (i TokenType) String() string {
-	if i >= TokenType(len(_TokenType_index)-1) {
-		return fmt.Sprintf("TokenType(%d)", i)
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_TokenType_index)-1 {
+		return "TokenType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _TokenType_name[_TokenType_index[i]:_TokenType_index[i+1]]
+	return _TokenType_name[_TokenType_index[idx]:_TokenType_index[idx+1]]
 }